@@ -0,0 +1,161 @@
+package validations
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DoAll validates each config, flags upstreams shared by more than one of them and enforces the per-namespace limit,
+// the result is keyed by the config's namespaced name
+func (v Validator) DoAll(configs []*registrycache.RegistryCacheConfig) map[string]field.ErrorList {
+	result, _ := v.DoAllWithWarnings(configs)
+	return result
+}
+
+// DoAllWithWarnings is DoAll that also returns the warnings of each config keyed by the config's namespaced name,
+// including the errors of warn-only rules
+func (v Validator) DoAllWithWarnings(configs []*registrycache.RegistryCacheConfig) (map[string]field.ErrorList, map[string]field.ErrorList) {
+	v.recorder = newRuleRecorder()
+
+	result := make(map[string]field.ErrorList, len(configs))
+	warnings := make(map[string]field.ErrorList, len(configs))
+	configsByUpstream := make(map[string][]*registrycache.RegistryCacheConfig)
+	configsByNamespace := make(map[string][]*registrycache.RegistryCacheConfig)
+	configsBySecret := make(map[types.NamespacedName][]*registrycache.RegistryCacheConfig)
+
+	for i, config := range configs {
+		key := configKey(config)
+		errs, configWarnings := v.DoWithWarnings(config)
+		result[key] = append(result[key], errs...)
+		warnings[key] = append(warnings[key], configWarnings...)
+
+		if v.options.Progress != nil {
+			v.options.Progress(i+1, len(configs))
+		}
+
+		normalized := normalizeUpstream(config.Spec.Upstream)
+		configsByUpstream[normalized] = append(configsByUpstream[normalized], config)
+		configsByNamespace[config.Namespace] = append(configsByNamespace[config.Namespace], config)
+
+		if config.Spec.SecretReferenceName != nil {
+			secret := types.NamespacedName{Namespace: config.Namespace, Name: *config.Spec.SecretReferenceName}
+			configsBySecret[secret] = append(configsBySecret[secret], config)
+		}
+	}
+
+	for secret, secretConfigs := range configsBySecret {
+		if len(secretConfigs) > 1 {
+			v.consolidateSharedSecretErrors(result, secret.Name, secretConfigs)
+		}
+	}
+
+	if limit := v.options.MaxConfigsPerNamespace; limit > 0 {
+		for namespace, namespaceConfigs := range configsByNamespace {
+			if len(namespaceConfigs) <= limit {
+				continue
+			}
+
+			// the configs up to the limit are accepted, only the ones exceeding it are rejected
+			for _, config := range namespaceConfigs[limit:] {
+				v.checkConfig(result, warnings, configKey(config), RuleNamespaceLimit, func() field.ErrorList {
+					return field.ErrorList{field.Forbidden(field.NewPath("metadata").Child("namespace"), fmt.Sprintf("namespace %q has %d registry cache configs which exceeds the limit of %d", namespace, len(namespaceConfigs), limit))}
+				})
+			}
+		}
+	}
+
+	for namespace, namespaceConfigs := range configsByNamespace {
+		if budget, found := v.options.NamespaceStorageBudget[namespace]; found {
+			v.enforceStorageBudget(result, warnings, namespace, budget, namespaceConfigs)
+		}
+	}
+
+	upstreamPath := field.NewPath("spec").Child("upstream")
+
+	for _, config := range configs {
+		v.checkConfig(result, warnings, configKey(config), RuleUpstreamUnique, func() field.ErrorList {
+			if len(configsByUpstream[normalizeUpstream(config.Spec.Upstream)]) > 1 {
+				return field.ErrorList{field.Duplicate(upstreamPath, config.Spec.Upstream)}
+			}
+
+			return nil
+		})
+	}
+
+	return result, warnings
+}
+
+// checkConfig runs a rule spanning several configs for the config with the given key, the errors of a warn-only rule
+// end up in the config's warnings instead of being dropped
+func (v Validator) checkConfig(result, warnings map[string]field.ErrorList, key, rule string, fn func() field.ErrorList) {
+	errs, demoted := v.rules().checkWithWarnings(rule, func() (field.ErrorList, field.ErrorList) {
+		return fn(), nil
+	})
+
+	if len(errs) > 0 {
+		result[key] = append(result[key], errs...)
+		sortErrors(result[key])
+	}
+
+	if len(demoted) > 0 {
+		warnings[key] = append(warnings[key], demoted...)
+		sortErrors(warnings[key])
+	}
+}
+
+// enforceStorageBudget sums the requested volume sizes in the order of the configs and rejects every config
+// that leaves the sum above the budget of the namespace
+func (v Validator) enforceStorageBudget(result, warnings map[string]field.ErrorList, namespace string, budget resource.Quantity, configs []*registrycache.RegistryCacheConfig) {
+	sum := resource.Quantity{Format: budget.Format}
+
+	for _, config := range configs {
+		if config.Spec.Volume == nil || config.Spec.Volume.Size == nil {
+			continue
+		}
+
+		sum.Add(*config.Spec.Volume.Size)
+
+		v.checkConfig(result, warnings, configKey(config), RuleNamespaceStorageBudget, func() field.ErrorList {
+			if sum.Cmp(budget) <= 0 {
+				return nil
+			}
+
+			overage := sum.DeepCopy()
+			overage.Sub(budget)
+
+			return field.ErrorList{field.Forbidden(field.NewPath("spec").Child("volume").Child("size"), fmt.Sprintf("namespace %q has a storage budget of %s, the requested volume sizes add up to %s which exceeds it by %s",
+				namespace, budget.String(), sum.String(), overage.String()))}
+		})
+	}
+}
+
+// consolidateSharedSecretErrors replaces the immutability error of each config referencing the same mutable secret
+// with one that names all of them, since making the secret immutable fixes every one of them at once
+func (v Validator) consolidateSharedSecretErrors(result map[string]field.ErrorList, secretName string, configs []*registrycache.RegistryCacheConfig) {
+	keys := make([]string, 0, len(configs))
+	for _, config := range configs {
+		keys = append(keys, configKey(config))
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		for i, err := range result[key] {
+			if v.recorder.ruleOf(err) != RuleSecretImmutable {
+				continue
+			}
+
+			result[key][i] = field.Invalid(field.NewPath("spec").Child("secretReferenceName"), secretName,
+				fmt.Sprintf("should be immutable, the secret is shared by the configs %s which are all affected by changes to it", strings.Join(keys, ", ")))
+		}
+	}
+}
+
+func configKey(config *registrycache.RegistryCacheConfig) string {
+	return types.NamespacedName{Namespace: config.Namespace, Name: config.Name}.String()
+}
@@ -0,0 +1,326 @@
+package validations
+
+import (
+	"fmt"
+	"testing"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+func TestDoAll(t *testing.T) {
+	upstreamFieldPath := field.NewPath("spec").Child("upstream")
+
+	namespaceFieldPath := field.NewPath("metadata").Child("namespace")
+	secretReferenceNameFieldPath := field.NewPath("spec").Child("secretReferenceName")
+	volumeSizeFieldPath := field.NewPath("spec").Child("volume").Child("size")
+
+	newConfigInNamespace := func(namespace, name, upstream string) *registrycache.RegistryCacheConfig {
+		return &registrycache.RegistryCacheConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: upstream,
+			},
+		}
+	}
+
+	newConfig := func(name, upstream string) *registrycache.RegistryCacheConfig {
+		return newConfigInNamespace("default", name, upstream)
+	}
+
+	newConfigWithSize := func(name, upstream, size string) *registrycache.RegistryCacheConfig {
+		config := newConfig(name, upstream)
+		config.Spec.Volume = &registrycache.Volume{Size: ptr.To(resource.MustParse(size))}
+
+		return config
+	}
+
+	newConfigWithSecret := func(name, upstream, secretName string) *registrycache.RegistryCacheConfig {
+		config := newConfig(name, upstream)
+		config.Spec.SecretReferenceName = ptr.To(secretName)
+
+		return config
+	}
+
+	newSecret := func(name string, immutable bool) v1.Secret {
+		return v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"username": []byte("dXNlcg=="),
+				"password": []byte("cGFzc3dvcmQ="),
+			},
+			Immutable: ptr.To(immutable),
+		}
+	}
+
+	for _, tt := range []struct {
+		name    string
+		configs []*registrycache.RegistryCacheConfig
+		secrets []v1.Secret
+		options ValidationOptions
+		errors  map[string]field.ErrorList
+	}{
+		{
+			name: "distinct upstreams",
+			configs: []*registrycache.RegistryCacheConfig{
+				newConfig("docker", "docker.io"),
+				newConfig("quay", "quay.io"),
+			},
+			errors: map[string]field.ErrorList{
+				"default/docker": {},
+				"default/quay":   {},
+			},
+		},
+		{
+			name: "duplicated normalized upstreams",
+			configs: []*registrycache.RegistryCacheConfig{
+				newConfig("docker", "docker.io"),
+				newConfig("docker-https", "Docker.io:443"),
+				newConfig("quay", "quay.io"),
+			},
+			errors: map[string]field.ErrorList{
+				"default/docker": {
+					field.Duplicate(upstreamFieldPath, "docker.io"),
+				},
+				"default/docker-https": {
+					field.Duplicate(upstreamFieldPath, "Docker.io:443"),
+				},
+				"default/quay": {},
+			},
+		},
+		{
+			name: "single config errors are kept",
+			configs: []*registrycache.RegistryCacheConfig{
+				newConfig("docker", "docker.io:77777"),
+				newConfig("docker-default-port", "docker.io"),
+			},
+			errors: map[string]field.ErrorList{
+				"default/docker": {
+					field.Invalid(upstreamFieldPath, "docker.io:77777", "valid port must be in the range [1, 65535]"),
+				},
+				"default/docker-default-port": {},
+			},
+		},
+		{
+			name: "configs exceeding the namespace limit",
+			configs: []*registrycache.RegistryCacheConfig{
+				newConfig("docker", "docker.io"),
+				newConfig("quay", "quay.io"),
+				newConfig("ghcr", "ghcr.io"),
+				newConfigInNamespace("other", "gcr", "gcr.io"),
+			},
+			options: ValidationOptions{
+				MaxConfigsPerNamespace: 2,
+			},
+			errors: map[string]field.ErrorList{
+				"default/docker": {},
+				"default/quay":   {},
+				"default/ghcr": {
+					field.Forbidden(namespaceFieldPath, `namespace "default" has 3 registry cache configs which exceeds the limit of 2`),
+				},
+				"other/gcr": {},
+			},
+		},
+		{
+			name: "configs exceeding the namespace storage budget",
+			configs: []*registrycache.RegistryCacheConfig{
+				newConfigWithSize("docker", "docker.io", "40Gi"),
+				newConfigWithSize("quay", "quay.io", "30Gi"),
+				newConfig("gcr", "gcr.io"),
+				newConfigWithSize("ghcr", "ghcr.io", "20Gi"),
+				newConfigInNamespace("other", "mcr", "mcr.microsoft.com"),
+			},
+			options: ValidationOptions{
+				NamespaceStorageBudget: map[string]resource.Quantity{"default": resource.MustParse("50Gi")},
+			},
+			errors: map[string]field.ErrorList{
+				"default/docker": {},
+				"default/quay": {
+					field.Forbidden(volumeSizeFieldPath, `namespace "default" has a storage budget of 50Gi, the requested volume sizes add up to 70Gi which exceeds it by 20Gi`),
+				},
+				"default/gcr": {},
+				"default/ghcr": {
+					field.Forbidden(volumeSizeFieldPath, "the requested volume sizes add up to 90Gi which exceeds it by 40Gi"),
+				},
+				"other/mcr": {},
+			},
+		},
+		{
+			name: "mutable secret shared by several configs",
+			configs: []*registrycache.RegistryCacheConfig{
+				newConfigWithSecret("docker", "docker.io", "shared"),
+				newConfigWithSecret("quay", "quay.io", "shared"),
+				newConfigWithSecret("ghcr", "ghcr.io", "own"),
+			},
+			secrets: []v1.Secret{newSecret("shared", false), newSecret("own", false)},
+			errors: map[string]field.ErrorList{
+				"default/docker": {
+					field.Invalid(secretReferenceNameFieldPath, "shared", "the secret is shared by the configs default/docker, default/quay"),
+				},
+				"default/quay": {
+					field.Invalid(secretReferenceNameFieldPath, "shared", "the secret is shared by the configs default/docker, default/quay"),
+				},
+				"default/ghcr": {
+					field.Invalid(secretReferenceNameFieldPath, "own", "should be immutable"),
+				},
+			},
+		},
+		{
+			name: "immutable secret shared by several configs",
+			configs: []*registrycache.RegistryCacheConfig{
+				newConfigWithSecret("docker", "docker.io", "shared"),
+				newConfigWithSecret("quay", "quay.io", "shared"),
+			},
+			secrets: []v1.Secret{newSecret("shared", true)},
+			errors: map[string]field.ErrorList{
+				"default/docker": {},
+				"default/quay":   {},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			result := NewValidatorWithOptions(tt.secrets, nil, tt.options).DoAll(tt.configs)
+
+			require.Len(t, result, len(tt.errors))
+
+			for key, expectedErrs := range tt.errors {
+				requireErrorsMatch(t, expectedErrs, result[key])
+			}
+		})
+	}
+}
+
+func TestDoAllWithWarnings(t *testing.T) {
+	upstreamFieldPath := field.NewPath("spec").Child("upstream")
+
+	newConfig := func(name, upstream string) *registrycache.RegistryCacheConfig {
+		return &registrycache.RegistryCacheConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: upstream,
+				Volume: &registrycache.Volume{
+					Size: ptr.To(resource.MustParse("10Gi")),
+				},
+			},
+		}
+	}
+
+	configs := []*registrycache.RegistryCacheConfig{
+		newConfig("docker", "docker.io"),
+		newConfig("docker-443", "docker.io:443"),
+	}
+
+	validator := NewValidatorWithOptions(nil, nil, ValidationOptions{
+		WarnOnlyRules: map[string]bool{RuleUpstreamUnique: true},
+	})
+
+	result, warnings := validator.DoAllWithWarnings(configs)
+
+	require.Empty(t, result["default/docker"])
+	require.Empty(t, result["default/docker-443"])
+	requireErrorsMatch(t, field.ErrorList{field.Duplicate(upstreamFieldPath, "docker.io")}, warnings["default/docker"])
+	requireErrorsMatch(t, field.ErrorList{field.Duplicate(upstreamFieldPath, "docker.io:443")}, warnings["default/docker-443"])
+
+	// DoAll has nowhere to report the warnings, the demoted errors must not block either way
+	for _, errs := range validator.DoAll(configs) {
+		require.Empty(t, errs)
+	}
+}
+
+func TestDoAllProgress(t *testing.T) {
+	configs, secrets := benchmarkConfigsAndSecrets(3)
+
+	var calls [][2]int
+	options := ValidationOptions{
+		Progress: func(done, total int) {
+			calls = append(calls, [2]int{done, total})
+		},
+	}
+
+	NewValidatorWithOptions(secrets, nil, options).DoAll(configs)
+
+	require.Equal(t, [][2]int{{1, 3}, {2, 3}, {3, 3}}, calls)
+}
+
+func BenchmarkDoAll(b *testing.B) {
+	configs, secrets := benchmarkConfigsAndSecrets(500)
+	validator := NewValidator(secrets, nil)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		validator.DoAll(configs)
+	}
+}
+
+// BenchmarkDoAllScanningSecrets is the baseline for BenchmarkDoAll, it looks every secret up by scanning the slice
+func BenchmarkDoAllScanningSecrets(b *testing.B) {
+	configs, secrets := benchmarkConfigsAndSecrets(500)
+	validator := NewValidatorWithSecretGetter(scanningSecretGetter(secrets), nil, ValidationOptions{})
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		validator.DoAll(configs)
+	}
+}
+
+type scanningSecretGetter []v1.Secret
+
+func (s scanningSecretGetter) Get(namespace, name string) (*v1.Secret, bool, error) {
+	for i := range s {
+		if s[i].Namespace == namespace && s[i].Name == name {
+			return &s[i], true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+func benchmarkConfigsAndSecrets(count int) ([]*registrycache.RegistryCacheConfig, []v1.Secret) {
+	configs := make([]*registrycache.RegistryCacheConfig, 0, count)
+	secrets := make([]v1.Secret, 0, count)
+
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("registry-%d", i)
+
+		secrets = append(secrets, v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"username": []byte("dXNlcg=="),
+				"password": []byte("cGFzc3dvcmQ="),
+			},
+			Immutable: ptr.To(true),
+		})
+
+		configs = append(configs, &registrycache.RegistryCacheConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+			},
+			Spec: registrycache.RegistryCacheConfigSpec{
+				Upstream:            fmt.Sprintf("%s.example.com:5000", name),
+				SecretReferenceName: ptr.To(name),
+			},
+		})
+	}
+
+	return configs, secrets
+}
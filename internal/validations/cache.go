@@ -0,0 +1,125 @@
+package validations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// CachingValidator remembers validation results keyed by the config spec and the resource version of the referenced secret,
+// so that reconciling an unchanged config does not repeat expensive checks
+type CachingValidator struct {
+	validator Validator
+	ttl       time.Duration
+	maxSize   int
+	now       func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	errs      field.ErrorList
+	expiresAt time.Time
+}
+
+// NewCachingValidator keeps at most maxSize results for ttl each, the oldest result is evicted when the cache is full
+func NewCachingValidator(validator Validator, ttl time.Duration, maxSize int) *CachingValidator {
+	return &CachingValidator{
+		validator: validator,
+		ttl:       ttl,
+		maxSize:   maxSize,
+		now:       time.Now,
+		entries:   make(map[string]cacheEntry),
+	}
+}
+
+func (c *CachingValidator) Do(newConfig *registrycache.RegistryCacheConfig) field.ErrorList {
+	key, ok := c.cacheKey(newConfig)
+	if !ok {
+		return c.validator.Do(newConfig)
+	}
+
+	now := c.now()
+
+	c.mu.Lock()
+	entry, found := c.entries[key]
+	c.mu.Unlock()
+
+	if found && now.Before(entry.expiresAt) {
+		return slices.Clone(entry.errs)
+	}
+
+	errs := c.validator.Do(newConfig)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, found := c.entries[key]; !found && len(c.entries) >= c.maxSize {
+		c.evictOldest(now)
+	}
+
+	if c.maxSize > 0 {
+		c.entries[key] = cacheEntry{errs: slices.Clone(errs), expiresAt: now.Add(c.ttl)}
+	}
+
+	return errs
+}
+
+// cacheKey hashes the namespace and spec together with the resource version of the referenced secret,
+// ok is false when the secret lookup fails so that the error is reported instead of cached
+func (c *CachingValidator) cacheKey(config *registrycache.RegistryCacheConfig) (string, bool) {
+	var secretVersion string
+
+	if name := config.Spec.SecretReferenceName; name != nil && c.validator.secretGetter != nil {
+		secret, found, err := c.validator.secretGetter.Get(config.Namespace, *name)
+		if err != nil {
+			return "", false
+		}
+
+		if found {
+			secretVersion = secret.ResourceVersion
+		}
+	}
+
+	spec, err := json.Marshal(config.Spec)
+	if err != nil {
+		return "", false
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(config.Namespace))
+	hash.Write([]byte{0})
+	hash.Write(spec)
+	hash.Write([]byte{0})
+	hash.Write([]byte(secretVersion))
+
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// evictOldest drops the expired entries, or the entry closest to expiry when none has expired yet
+func (c *CachingValidator) evictOldest(now time.Time) {
+	var oldestKey string
+	var oldestExpiry time.Time
+
+	for key, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, key)
+			continue
+		}
+
+		if oldestKey == "" || entry.expiresAt.Before(oldestExpiry) {
+			oldestKey, oldestExpiry = key, entry.expiresAt
+		}
+	}
+
+	if len(c.entries) >= c.maxSize && oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
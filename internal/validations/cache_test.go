@@ -0,0 +1,117 @@
+package validations
+
+import (
+	"testing"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+type countingStorageClassLister struct {
+	calls int
+}
+
+func (c *countingStorageClassLister) Exists(string) (bool, error) {
+	c.calls++
+	return true, nil
+}
+
+func TestCachingValidator(t *testing.T) {
+	newConfig := func(upstream string) *registrycache.RegistryCacheConfig {
+		return &registrycache.RegistryCacheConfig{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+			},
+			Spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: upstream,
+				Volume: &registrycache.Volume{
+					StorageClassName: ptr.To("standard"),
+				},
+				SecretReferenceName: ptr.To("registry-credentials"),
+			},
+		}
+	}
+
+	newSecret := func(resourceVersion string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "registry-credentials",
+				Namespace:       "default",
+				ResourceVersion: resourceVersion,
+			},
+			Data: map[string][]byte{
+				"username": []byte("dXNlcg=="),
+				"password": []byte("cGFzc3dvcmQ="),
+			},
+		}
+	}
+
+	setup := func(maxSize int) (*CachingValidator, *countingStorageClassLister, *fakeSecretGetter, *time.Time) {
+		lister := &countingStorageClassLister{}
+		secretGetter := &fakeSecretGetter{secret: newSecret("1")}
+		now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		validator := NewCachingValidator(NewValidatorWithSecretGetter(secretGetter, nil, ValidationOptions{StorageClassLister: lister}), time.Minute, maxSize)
+		validator.now = func() time.Time { return now }
+
+		return validator, lister, secretGetter, &now
+	}
+
+	t.Run("unchanged config is served from the cache", func(t *testing.T) {
+		validator, lister, _, _ := setup(10)
+
+		first := validator.Do(newConfig("docker.io"))
+		second := validator.Do(newConfig("docker.io"))
+
+		require.Equal(t, 1, lister.calls)
+		require.Equal(t, first, second)
+		require.Len(t, second, 1)
+	})
+
+	t.Run("changed spec is recomputed", func(t *testing.T) {
+		validator, lister, _, _ := setup(10)
+
+		validator.Do(newConfig("docker.io"))
+		validator.Do(newConfig("quay.io"))
+
+		require.Equal(t, 2, lister.calls)
+	})
+
+	t.Run("new secret version busts the cache", func(t *testing.T) {
+		validator, lister, secretGetter, _ := setup(10)
+
+		require.Len(t, validator.Do(newConfig("docker.io")), 1)
+
+		secretGetter.secret = newSecret("2")
+		secretGetter.secret.Immutable = ptr.To(true)
+
+		require.Empty(t, validator.Do(newConfig("docker.io")))
+		require.Equal(t, 2, lister.calls)
+	})
+
+	t.Run("expired entry is recomputed", func(t *testing.T) {
+		validator, lister, _, now := setup(10)
+
+		validator.Do(newConfig("docker.io"))
+		*now = now.Add(2 * time.Minute)
+		validator.Do(newConfig("docker.io"))
+
+		require.Equal(t, 2, lister.calls)
+	})
+
+	t.Run("oldest entry is evicted when the cache is full", func(t *testing.T) {
+		validator, lister, _, now := setup(1)
+
+		validator.Do(newConfig("docker.io"))
+		*now = now.Add(time.Second)
+		validator.Do(newConfig("quay.io"))
+		validator.Do(newConfig("docker.io"))
+
+		require.Equal(t, 3, lister.calls)
+		require.Len(t, validator.entries, 1)
+	})
+}
@@ -0,0 +1,79 @@
+package validations
+
+import (
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+)
+
+// remediations holds a static hint per rule ID telling the operator how to fix a failure of the rule
+var remediations = map[string]string{
+	RuleUpstreamRequired:   "set spec.upstream to the host of the registry to cache, e.g. docker.io",
+	RuleUpstreamUnixSocket: "expose the registry over TCP and set its host[:port] as the upstream",
+	RuleUpstreamScheme:     "remove the scheme, e.g. use docker.io instead of https://docker.io",
+	RuleUpstreamQuery:      "remove the query string and fragment, only host[:port] is permitted",
+	RuleUpstreamPath:       "remove the path and trailing slash, only host[:port] is permitted",
+	RuleUpstreamHost:       "use a lowercase DNS name or an IP address, enclose IPv6 addresses in brackets when setting a port",
+	RuleUpstreamPort:       "set a port between 1 and 65535, or omit the port",
+	RuleUpstreamUnique:     "remove one of the configs caching this upstream, each upstream can only be cached once",
+	RuleUpstreamPolicy:     "pick an upstream allowed by the platform policy or ask the platform operator to allow it",
+	RuleUpstreamPrivate:    "point the upstream to a public registry, private, link-local and loopback addresses are forbidden",
+	RuleUpstreamImmutable:  "revert spec.upstream or delete and recreate the config to cache another upstream",
+
+	RuleRemoteURLScheme: "start spec.remoteURL with http:// or https://",
+
+	RuleVolumeSizePositive: "set spec.volume.size to a positive quantity, e.g. 10Gi",
+	RuleVolumeSizeMax:      "lower spec.volume.size to at most the maximum allowed size",
+	RuleVolumeSizeShrink:   "keep spec.volume.size at least at its current value or recreate the config to start with a smaller volume",
+
+	RuleStorageClassNameFormat:    "use a lowercase RFC 1123 name consisting of letters, digits, '-' and '.'",
+	RuleStorageClassNameAllowed:   "pick one of the storage classes allowed by the platform",
+	RuleStorageClassNameExists:    "create the storage class first or pick one that exists in the cluster",
+	RuleStorageClassNameImmutable: "revert spec.volume.storageClassName or delete and recreate the config",
+
+	RuleGarbageCollectionTTLNonNegative: "set spec.garbageCollection.ttl to a positive duration such as 168h, or 0s to disable garbage collection",
+	RuleGarbageCollectionTTLMax:         "lower spec.garbageCollection.ttl to at most the maximum allowed ttl",
+	RuleGarbageCollectionTTLMin:         "raise spec.garbageCollection.ttl to at least the minimum allowed ttl, or set it to 0s to disable garbage collection",
+
+	RuleProxyAirGapped:     "remove spec.proxy, the registry cache has to reach the upstream directly in an air-gapped environment",
+	RuleProxyRequired:      "set httpProxy or httpsProxy, or remove spec.proxy",
+	RuleProxyURL:           "use a url of the form http://host:port",
+	RuleProxyScheme:        "use the http scheme for httpProxy and the https scheme for httpsProxy",
+	RuleProxyHost:          "use a valid DNS name or IP address and a port between 1 and 65535",
+	RuleProxyCredentials:   "remove the credentials from the proxy url and store them in the secret referenced by spec.secretReferenceName",
+	RuleProxyLoopback:      "point the proxy to an address reachable from the registry cache pod instead of localhost",
+	RuleProxyForbiddenCIDR: "point the proxy to an address outside of the cluster networks",
+
+	RuleSecretRequired:         "create a secret with the registry credentials and reference it in spec.secretReferenceName",
+	RuleSecretName:             "use a lowercase RFC 1123 name, the same rules as for any secret name apply",
+	RuleSecretNamespace:        "set metadata.namespace to the namespace of the referenced secret",
+	RuleSecretExists:           "create the secret in the namespace of the config or fix spec.secretReferenceName",
+	RuleSecretImmutable:        "set immutable: true on the secret",
+	RuleSecretType:             "recreate the secret with the type Opaque, kubernetes.io/dockerconfigjson or kubernetes.io/basic-auth",
+	RuleSecretKeyCasing:        "rename the secret keys to their exact lowercase names, e.g. username instead of Username",
+	RuleSecretStructure:        "store the credentials either in the username and password keys or in the .dockerconfigjson key",
+	RuleSecretDockerConfigJSON: "add an auths entry for the upstream to the .dockerconfigjson key",
+	RuleSecretCACertificate:    "store valid, unexpired PEM encoded CA certificates in the ca.crt key",
+	RuleSecretSize:             "remove unused keys from the secret to stay below the 1MiB limit",
+
+	RuleNamespaceLimit:         "delete unused configs in the namespace or ask the platform operator to raise the limit",
+	RuleNamespaceStorageBudget: "reduce spec.volume.size of the configs in the namespace or ask the platform operator to raise the storage budget",
+	RuleEmptyOptionalField:     "set a value or remove the field",
+}
+
+// ExplainedError is a validation error tagged with its rule ID and a hint how to fix it, the hint is empty when
+// the rule has none
+type ExplainedError struct {
+	RuleError
+	Remediation string
+}
+
+// DoExplain returns the same errors as Do together with their rule IDs and remediation hints
+func (v Validator) DoExplain(newConfig *registrycache.RegistryCacheConfig) []ExplainedError {
+	ruleErrs := v.DoWithRules(newConfig)
+
+	explained := make([]ExplainedError, 0, len(ruleErrs))
+	for _, ruleErr := range ruleErrs {
+		explained = append(explained, ExplainedError{RuleError: ruleErr, Remediation: remediations[ruleErr.RuleID]})
+	}
+
+	return explained
+}
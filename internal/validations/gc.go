@@ -0,0 +1,146 @@
+package validations
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func (v Validator) validateGarbageCollection(gc *registrycache.GarbageCollection, fldPath *field.Path) field.ErrorList {
+	if gc == nil {
+		return nil
+	}
+
+	return v.validateGarbageCollectionTTL(gc.TTL.Duration, fldPath.Child("ttl"))
+}
+
+// ValidateGarbageCollectionTTL checks a single garbage collection ttl against the default options
+func ValidateGarbageCollectionTTL(ttl time.Duration, fldPath *field.Path) field.ErrorList {
+	return Validator{}.validateGarbageCollectionTTL(ttl, fldPath)
+}
+
+func (v Validator) validateGarbageCollectionTTL(ttl time.Duration, fldPath *field.Path) field.ErrorList {
+	rules := v.rules()
+
+	if errs := rules.check(RuleGarbageCollectionTTLNonNegative, func() field.ErrorList {
+		if ttl < 0 {
+			return field.ErrorList{field.Invalid(fldPath, ttl, "ttl must be a non-negative duration")}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	if maxTTL := v.options.MaxGarbageCollectionTTL; maxTTL != nil {
+		if errs := rules.check(RuleGarbageCollectionTTLMax, func() field.ErrorList {
+			if ttl > *maxTTL {
+				return field.ErrorList{field.Invalid(fldPath, ttl, fmt.Sprintf("ttl %s exceeds the maximum allowed ttl %s", formatDuration(ttl), formatDuration(*maxTTL)))}
+			}
+
+			return nil
+		}); len(errs) > 0 {
+			return errs
+		}
+	}
+
+	minTTL := v.options.MinGarbageCollectionTTL
+	if minTTL == nil {
+		return nil
+	}
+
+	return rules.check(RuleGarbageCollectionTTLMin, func() field.ErrorList {
+		// zero disables the garbage collection, so the minimum only applies to positive values
+		if ttl > 0 && ttl < *minTTL {
+			return field.ErrorList{field.Invalid(fldPath, ttl, fmt.Sprintf("ttl %s is below the minimum allowed ttl %s, set it to 0s to disable garbage collection as positive values this small are likely a mistake", formatDuration(ttl), formatDuration(*minTTL)))}
+		}
+
+		return nil
+	})
+}
+
+func (v Validator) warnOnGarbageCollection(spec registrycache.RegistryCacheConfigSpec, fldPath *field.Path) field.ErrorList {
+	var warnings field.ErrorList
+
+	if spec.GarbageCollection != nil {
+		warnings = v.rules().check(RuleGarbageCollectionTTLWholeHours, func() field.ErrorList {
+			return warnOnPartialHourTTL(spec.GarbageCollection.TTL.Duration, fldPath.Child("ttl"))
+		})
+	}
+
+	return append(warnings, v.warnOnGarbageCollectionVolume(spec, fldPath)...)
+}
+
+// warnOnPartialHourTTL flags ttls the garbage collection backend truncates since it only supports whole hours
+func warnOnPartialHourTTL(ttl time.Duration, fldPath *field.Path) field.ErrorList {
+	if ttl <= 0 || ttl%time.Hour == 0 {
+		return nil
+	}
+
+	effective := ttl.Truncate(time.Hour)
+
+	detail := fmt.Sprintf("ttl %s is not a whole number of hours, garbage collection truncates it to %s", formatDuration(ttl), formatDuration(effective))
+	if effective == 0 {
+		detail += " which disables it"
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, ttl, detail)}
+}
+
+func (v Validator) warnOnGarbageCollectionVolume(spec registrycache.RegistryCacheConfigSpec, fldPath *field.Path) field.ErrorList {
+	if spec.Volume == nil || spec.Volume.Size == nil {
+		return nil
+	}
+
+	if spec.GarbageCollection == nil {
+		return v.warnOnMissingGarbageCollection(*spec.Volume.Size, fldPath)
+	}
+
+	longTTL, smallSize := v.options.LongGarbageCollectionTTL, v.options.SmallVolumeSize
+	if longTTL == nil || smallSize == nil {
+		return nil
+	}
+
+	return v.rules().check(RuleGarbageCollectionTTLSmallVolume, func() field.ErrorList {
+		ttl, size := spec.GarbageCollection.TTL.Duration, spec.Volume.Size
+		if ttl <= *longTTL || size.Cmp(*smallSize) >= 0 {
+			return nil
+		}
+
+		return field.ErrorList{field.Invalid(fldPath.Child("ttl"), ttl, fmt.Sprintf("ttl %s combined with a volume size of %s is likely to fill up the cache, consider a larger volume or a shorter ttl", formatDuration(ttl), size.String()))}
+	})
+}
+
+func (v Validator) warnOnMissingGarbageCollection(size resource.Quantity, fldPath *field.Path) field.ErrorList {
+	threshold := v.options.GarbageCollectionVolumeSizeThreshold
+	if threshold == nil {
+		return nil
+	}
+
+	return v.rules().check(RuleGarbageCollectionUnset, func() field.ErrorList {
+		if size.Cmp(*threshold) >= 0 {
+			return nil
+		}
+
+		return field.ErrorList{field.Required(fldPath, fmt.Sprintf("garbage collection is not configured so the default ttl of %s applies, which may be too long for a volume of %s, consider setting a shorter ttl", formatDuration(defaultGarbageCollectionTTL), size.String()))}
+	})
+}
+
+// formatDuration drops the zero minutes and seconds that time.Duration.String appends, so 720h is not rendered as 720h0m0s
+func formatDuration(d time.Duration) string {
+	s := d.String()
+
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+
+	return s
+}
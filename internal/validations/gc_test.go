@@ -0,0 +1,40 @@
+package validations
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateGarbageCollectionTTL(t *testing.T) {
+	fldPath := field.NewPath("ttl")
+
+	for _, tt := range []struct {
+		name       string
+		ttl        time.Duration
+		errorsList field.ErrorList
+	}{
+		{
+			name:       "positive ttl",
+			ttl:        168 * time.Hour,
+			errorsList: field.ErrorList{},
+		},
+		{
+			name:       "zero ttl disabling garbage collection",
+			ttl:        0,
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "negative ttl",
+			ttl:  -time.Hour,
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, -time.Hour, "ttl must be a non-negative duration"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requireErrorsMatch(t, tt.errorsList, ValidateGarbageCollectionTTL(tt.ttl, fldPath))
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package validations
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// defaultVolumeSize and defaultGarbageCollectionTTL are the defaults documented on the API types
+var (
+	defaultVolumeSize           = resource.MustParse("10Gi")
+	defaultGarbageCollectionTTL = 168 * time.Hour
+)
+
+// Normalize rewrites the upstream, remote url and proxy urls of the config in place into their canonical form,
+// values that cannot be parsed are left untouched so that the validation still reports them
+func Normalize(config *registrycache.RegistryCacheConfig) {
+	spec := &config.Spec
+
+	upstream := strings.TrimRight(spec.Upstream, "/")
+	if _, _, err := parseUpstream(upstream); err == nil {
+		spec.Upstream = normalizeUpstream(upstream)
+	}
+
+	normalizeURL(spec.RemoteURL, true)
+
+	// explicit proxy ports are kept since a proxy url without a port triggers a warning
+	if spec.Proxy != nil {
+		normalizeURL(spec.Proxy.HTTPProxy, false)
+		normalizeURL(spec.Proxy.HTTPSProxy, false)
+	}
+}
+
+// Default sets the volume size and the garbage collection of the config in place to the API defaults when they are unset
+func Default(config *registrycache.RegistryCacheConfig) {
+	spec := &config.Spec
+
+	if spec.Volume == nil {
+		spec.Volume = &registrycache.Volume{}
+	}
+
+	if spec.Volume.Size == nil {
+		spec.Volume.Size = ptr.To(defaultVolumeSize.DeepCopy())
+	}
+
+	if spec.GarbageCollection == nil {
+		spec.GarbageCollection = &registrycache.GarbageCollection{TTL: metav1.Duration{Duration: defaultGarbageCollectionTTL}}
+	}
+}
+
+// DoAndDefault normalizes and defaults a deep copy of the config and validates it, so the errors refer to the values the
+// controller acts on, the config itself is left untouched
+func (v Validator) DoAndDefault(config *registrycache.RegistryCacheConfig) (field.ErrorList, *registrycache.RegistryCacheConfig) {
+	defaulted := config.DeepCopy()
+	Normalize(defaulted)
+	Default(defaulted)
+
+	return v.Do(defaulted), defaulted
+}
+
+// normalizeURL lowercases the scheme and host, optionally drops the default port of the scheme and trims trailing slashes
+func normalizeURL(rawURL *string, stripDefaultPort bool) {
+	if rawURL == nil {
+		return
+	}
+
+	parsed, err := url.Parse(*rawURL)
+	if err != nil || parsed.Host == "" {
+		return
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	defaultPort, found := defaultPorts[scheme]
+	if !found {
+		return
+	}
+
+	host, port := strings.ToLower(parsed.Hostname()), parsed.Port()
+
+	// a colon in an unbracketed host is a malformed port rather than an IPv6 address
+	if strings.Contains(host, ":") && !strings.HasPrefix(parsed.Host, "[") {
+		return
+	}
+
+	switch {
+	case port != "" && (port != defaultPort || !stripDefaultPort):
+		parsed.Host = net.JoinHostPort(host, port)
+	case strings.Contains(host, ":"):
+		parsed.Host = "[" + host + "]"
+	default:
+		parsed.Host = host
+	}
+
+	parsed.Scheme = scheme
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	parsed.RawPath = strings.TrimRight(parsed.RawPath, "/")
+
+	*rawURL = parsed.String()
+}
@@ -0,0 +1,185 @@
+package validations
+
+import (
+	"testing"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		spec     registrycache.RegistryCacheConfigSpec
+		expected registrycache.RegistryCacheConfigSpec
+	}{
+		{
+			name:     "canonical upstream",
+			spec:     registrycache.RegistryCacheConfigSpec{Upstream: "docker.io"},
+			expected: registrycache.RegistryCacheConfigSpec{Upstream: "docker.io"},
+		},
+		{
+			name:     "upstream with uppercase host, default port and trailing slash",
+			spec:     registrycache.RegistryCacheConfigSpec{Upstream: "Registry.Example.com:443/"},
+			expected: registrycache.RegistryCacheConfigSpec{Upstream: "registry.example.com"},
+		},
+		{
+			name:     "upstream with custom port",
+			spec:     registrycache.RegistryCacheConfigSpec{Upstream: "Registry.Example.com:5000"},
+			expected: registrycache.RegistryCacheConfigSpec{Upstream: "registry.example.com:5000"},
+		},
+		{
+			name:     "invalid upstream is left untouched",
+			spec:     registrycache.RegistryCacheConfigSpec{Upstream: "HTTPS://Docker.io:443"},
+			expected: registrycache.RegistryCacheConfigSpec{Upstream: "HTTPS://Docker.io:443"},
+		},
+		{
+			name: "remote url with default ports",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream:  "docker.io",
+				RemoteURL: ptr.To("HTTPS://Registry-1.Docker.io:443/"),
+			},
+			expected: registrycache.RegistryCacheConfigSpec{
+				Upstream:  "docker.io",
+				RemoteURL: ptr.To("https://registry-1.docker.io"),
+			},
+		},
+		{
+			name: "proxy urls",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Proxy: &registrycache.Proxy{
+					HTTPProxy:  ptr.To("http://Proxy.Corp:80/"),
+					HTTPSProxy: ptr.To("https://[FD00::1]:8443//"),
+				},
+			},
+			expected: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Proxy: &registrycache.Proxy{
+					HTTPProxy:  ptr.To("http://proxy.corp:80"),
+					HTTPSProxy: ptr.To("https://[fd00::1]:8443"),
+				},
+			},
+		},
+		{
+			name: "malformed proxy urls are left untouched",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Proxy: &registrycache.Proxy{
+					HTTPProxy:  ptr.To("Proxy.Corp:80"),
+					HTTPSProxy: ptr.To("socks5://Proxy.Corp:1080"),
+				},
+			},
+			expected: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Proxy: &registrycache.Proxy{
+					HTTPProxy:  ptr.To("Proxy.Corp:80"),
+					HTTPSProxy: ptr.To("socks5://Proxy.Corp:1080"),
+				},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &registrycache.RegistryCacheConfig{Spec: tt.spec}
+
+			Normalize(config)
+			require.Equal(t, tt.expected, config.Spec)
+
+			Normalize(config)
+			require.Equal(t, tt.expected, config.Spec, "normalizing twice must not change the result")
+		})
+	}
+}
+
+func TestDoAndDefault(t *testing.T) {
+	defaultVolume := &registrycache.Volume{Size: ptr.To(resource.MustParse("10Gi"))}
+	defaultGarbageCollection := &registrycache.GarbageCollection{TTL: metav1.Duration{Duration: 168 * time.Hour}}
+
+	for _, tt := range []struct {
+		name       string
+		spec       registrycache.RegistryCacheConfigSpec
+		options    ValidationOptions
+		expected   registrycache.RegistryCacheConfigSpec
+		errorsList field.ErrorList
+	}{
+		{
+			name: "config only valid after defaulting",
+			spec: registrycache.RegistryCacheConfigSpec{Upstream: "Registry.Example.com:443/"},
+			expected: registrycache.RegistryCacheConfigSpec{
+				Upstream:          "registry.example.com",
+				Volume:            defaultVolume,
+				GarbageCollection: defaultGarbageCollection,
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "config invalid regardless of defaulting",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream:  "Registry.Example.com:443/",
+				RemoteURL: ptr.To(InvalidRemoteURL),
+			},
+			expected: registrycache.RegistryCacheConfigSpec{
+				Upstream:          "registry.example.com",
+				RemoteURL:         ptr.To(InvalidRemoteURL),
+				Volume:            defaultVolume,
+				GarbageCollection: defaultGarbageCollection,
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec").Child("remoteURL"), InvalidRemoteURL, "url must start with 'http://' or 'https://'"),
+			},
+		},
+		{
+			name: "config only invalid after defaulting",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Volume:   &registrycache.Volume{StorageClassName: ptr.To("standard")},
+			},
+			options: ValidationOptions{
+				MaxVolumeSize:           ptr.To(resource.MustParse("5Gi")),
+				MaxGarbageCollectionTTL: ptr.To(72 * time.Hour),
+			},
+			expected: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Volume: &registrycache.Volume{
+					Size:             ptr.To(resource.MustParse("10Gi")),
+					StorageClassName: ptr.To("standard"),
+				},
+				GarbageCollection: defaultGarbageCollection,
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec").Child("garbageCollection").Child("ttl"), 168*time.Hour, "ttl 168h exceeds the maximum allowed ttl 72h"),
+				field.Invalid(field.NewPath("spec").Child("volume").Child("size"), "10Gi", "requested size 10Gi exceeds the maximum allowed size 5Gi"),
+			},
+		},
+		{
+			name: "explicit values are kept",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream:          "docker.io",
+				Volume:            &registrycache.Volume{Size: ptr.To(resource.MustParse("50Gi"))},
+				GarbageCollection: &registrycache.GarbageCollection{},
+			},
+			expected: registrycache.RegistryCacheConfigSpec{
+				Upstream:          "docker.io",
+				Volume:            &registrycache.Volume{Size: ptr.To(resource.MustParse("50Gi"))},
+				GarbageCollection: &registrycache.GarbageCollection{},
+			},
+			errorsList: field.ErrorList{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &registrycache.RegistryCacheConfig{Spec: tt.spec}
+			original := config.DeepCopy()
+
+			errs, defaulted := NewValidatorWithOptions(nil, nil, tt.options).DoAndDefault(config)
+
+			requireErrorsMatch(t, tt.errorsList, errs)
+			require.Equal(t, tt.expected, defaulted.Spec)
+			require.Equal(t, original, config, "the config passed in must not be modified")
+		})
+	}
+}
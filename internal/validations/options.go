@@ -0,0 +1,71 @@
+package validations
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// StorageClassLister reports whether a storage class exists in the cluster
+type StorageClassLister interface {
+	Exists(name string) (bool, error)
+}
+
+type ValidationOptions struct {
+	// MaxVolumeSize is the largest allowed spec.volume.size, no limit is enforced when nil
+	MaxVolumeSize *resource.Quantity
+	// AllowedStorageClassNames restricts spec.volume.storageClassName, any RFC 1123 compliant name is accepted when empty
+	AllowedStorageClassNames []string
+	// StorageClassLister checks that spec.volume.storageClassName exists, only the syntax is validated when nil
+	StorageClassLister StorageClassLister
+	// MaxGarbageCollectionTTL is the longest allowed spec.garbageCollection.ttl, no limit is enforced when nil
+	MaxGarbageCollectionTTL *time.Duration
+	// MinGarbageCollectionTTL is the shortest allowed positive spec.garbageCollection.ttl, no limit is enforced when nil
+	MinGarbageCollectionTTL *time.Duration
+	// LongGarbageCollectionTTL and SmallVolumeSize together enable a warning for long ttls on small volumes
+	LongGarbageCollectionTTL *time.Duration
+	SmallVolumeSize          *resource.Quantity
+	// GarbageCollectionVolumeSizeThreshold enables a warning when spec.garbageCollection is unset and spec.volume.size is below it
+	GarbageCollectionVolumeSizeThreshold *resource.Quantity
+	// VolumeSizeGranularity enables a warning when spec.volume.size is not a multiple of it, the check is skipped when nil
+	VolumeSizeGranularity *resource.Quantity
+	// MutableFields lists field paths, e.g. spec.upstream, that may change on update although they are immutable by default
+	MutableFields []string
+	// AllowedUpstreams and DeniedUpstreams are glob patterns, e.g. *.internal, matched case-insensitively against the upstream host
+	// without its port, an upstream must match the allow list when it is not empty and must not match the deny list
+	AllowedUpstreams []string
+	DeniedUpstreams  []string
+	// UpstreamsRequiringAuth are glob patterns matched like AllowedUpstreams, a matching upstream must set spec.secretReferenceName
+	UpstreamsRequiringAuth []string
+	// WellKnownRegistryPorts replaces the built-in table of public registries and their ports, an upstream naming one of them
+	// with the plaintext port 80 triggers a warning while one without a port is not warned about the assumed 443
+	WellKnownRegistryPorts map[string]int
+	// SelfHosts are the hosts the registry cache itself is published under, an upstream pointing at one of them triggers a warning
+	SelfHosts []string
+	// ForbidPrivateUpstream rejects upstreams whose host is a private, link-local or loopback IP literal, hostnames are not resolved
+	ForbidPrivateUpstream bool
+	// AirGapped forbids spec.proxy altogether since outbound proxies are not allowed in air-gapped environments
+	AirGapped bool
+	// ForbiddenProxyCIDRs rejects proxy urls whose host is an IP literal inside one of the ranges, e.g. the pod and service CIDRs
+	ForbiddenProxyCIDRs []netip.Prefix
+	// SecretSizeWarningRatio is the fraction of the secret size limit above which the referenced secret triggers a warning, 0.8 when unset
+	SecretSizeWarningRatio float64
+	// Progress is called by DoAll after each config is validated, always from the calling goroutine and in order
+	Progress func(done, total int)
+	// MaxConfigsPerNamespace is the number of configs DoAll accepts per namespace, no limit is enforced when 0
+	MaxConfigsPerNamespace int
+	// Strict rejects optional fields that are set but empty, e.g. an empty storageClassName or a volume without any setting
+	Strict bool
+	// NamespaceStorageBudget caps the sum of spec.volume.size across the configs DoAll validates per namespace,
+	// namespaces without an entry are not limited
+	NamespaceStorageBudget map[string]resource.Quantity
+	// DisabledRules skips the checks with the given rule IDs, see Rules for the known IDs
+	DisabledRules map[string]bool
+	// WarnOnlyRules reports the errors of the given rule IDs as warnings instead, a rule that is also disabled is skipped,
+	// entry points returning only errors drop them, e.g. DoAllWithWarnings returns them where DoAll does not
+	WarnOnlyRules map[string]bool
+	// Logger receives a V(2) line with the ID, outcome and duration of every evaluated rule, nothing is logged when unset
+	Logger logr.Logger
+}
@@ -0,0 +1,154 @@
+package validations
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Policy keys are the keys NewValidationPolicyFromMap accepts, e.g. the keys of a ConfigMap holding the platform policy
+const (
+	PolicyKeyMaxVolumeSize            = "maxVolumeSize"
+	PolicyKeyAllowedStorageClassNames = "allowedStorageClassNames"
+	PolicyKeyMaxGarbageCollectionTTL  = "maxGarbageCollectionTTL"
+	PolicyKeyMinGarbageCollectionTTL  = "minGarbageCollectionTTL"
+	PolicyKeyAllowedUpstreams         = "allowedUpstreams"
+	PolicyKeyDeniedUpstreams          = "deniedUpstreams"
+)
+
+// ValidationPolicy holds the centrally configured bounds, unset values leave the corresponding options untouched
+type ValidationPolicy struct {
+	MaxVolumeSize            *resource.Quantity
+	AllowedStorageClassNames []string
+	MaxGarbageCollectionTTL  *time.Duration
+	MinGarbageCollectionTTL  *time.Duration
+	AllowedUpstreams         []string
+	DeniedUpstreams          []string
+}
+
+// NewValidationPolicyFromMap parses quantities, Go durations and comma separated lists, all invalid values and unknown keys
+// are reported at once so that a misconfigured policy is caught before any config is validated
+func NewValidationPolicyFromMap(data map[string]string) (ValidationPolicy, error) {
+	var policy ValidationPolicy
+	var errs []error
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		value := strings.TrimSpace(data[key])
+
+		var err error
+		switch key {
+		case PolicyKeyMaxVolumeSize:
+			policy.MaxVolumeSize, err = parsePolicyQuantity(value)
+		case PolicyKeyAllowedStorageClassNames:
+			policy.AllowedStorageClassNames = parsePolicyList(value)
+		case PolicyKeyMaxGarbageCollectionTTL:
+			policy.MaxGarbageCollectionTTL, err = parsePolicyDuration(value)
+		case PolicyKeyMinGarbageCollectionTTL:
+			policy.MinGarbageCollectionTTL, err = parsePolicyDuration(value)
+		case PolicyKeyAllowedUpstreams:
+			policy.AllowedUpstreams, err = parsePolicyPatterns(value)
+		case PolicyKeyDeniedUpstreams:
+			policy.DeniedUpstreams, err = parsePolicyPatterns(value)
+		default:
+			err = errors.New("unknown policy key")
+		}
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return ValidationPolicy{}, fmt.Errorf("invalid validation policy: %w", errors.Join(errs...))
+	}
+
+	return policy, nil
+}
+
+func parsePolicyQuantity(value string) (*resource.Quantity, error) {
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quantity %q: %w", value, err)
+	}
+
+	return &quantity, nil
+}
+
+func parsePolicyDuration(value string) (*time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration %q: %w", value, err)
+	}
+
+	return &duration, nil
+}
+
+func parsePolicyPatterns(value string) ([]string, error) {
+	patterns := parsePolicyList(value)
+
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	return patterns, nil
+}
+
+func parsePolicyList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// ApplyTo overrides the options with the values set in the policy
+func (p ValidationPolicy) ApplyTo(options ValidationOptions) ValidationOptions {
+	if p.MaxVolumeSize != nil {
+		options.MaxVolumeSize = p.MaxVolumeSize
+	}
+
+	if len(p.AllowedStorageClassNames) > 0 {
+		options.AllowedStorageClassNames = p.AllowedStorageClassNames
+	}
+
+	if p.MaxGarbageCollectionTTL != nil {
+		options.MaxGarbageCollectionTTL = p.MaxGarbageCollectionTTL
+	}
+
+	if p.MinGarbageCollectionTTL != nil {
+		options.MinGarbageCollectionTTL = p.MinGarbageCollectionTTL
+	}
+
+	if len(p.AllowedUpstreams) > 0 {
+		options.AllowedUpstreams = p.AllowedUpstreams
+	}
+
+	if len(p.DeniedUpstreams) > 0 {
+		options.DeniedUpstreams = p.DeniedUpstreams
+	}
+
+	return options
+}
+
+// NewValidatorWithPolicy is a shorthand for NewValidatorWithOptions with the policy applied to the default options
+func NewValidatorWithPolicy(secrets []v1.Secret, existingConfigs []registrycache.RegistryCacheConfig, policy ValidationPolicy) Validator {
+	return NewValidatorWithOptions(secrets, existingConfigs, policy.ApplyTo(ValidationOptions{}))
+}
@@ -0,0 +1,119 @@
+package validations
+
+import (
+	"testing"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+func TestNewValidationPolicyFromMap(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		data     map[string]string
+		expected ValidationPolicy
+		errs     []string
+	}{
+		{
+			name:     "empty map",
+			data:     map[string]string{},
+			expected: ValidationPolicy{},
+		},
+		{
+			name: "all keys",
+			data: map[string]string{
+				PolicyKeyMaxVolumeSize:            "100Gi",
+				PolicyKeyAllowedStorageClassNames: "standard, premium-ssd,",
+				PolicyKeyMaxGarbageCollectionTTL:  "720h",
+				PolicyKeyMinGarbageCollectionTTL:  " 1h ",
+				PolicyKeyAllowedUpstreams:         "*.example.com,docker.io",
+				PolicyKeyDeniedUpstreams:          "mirror.example.com",
+			},
+			expected: ValidationPolicy{
+				MaxVolumeSize:            ptr.To(resource.MustParse("100Gi")),
+				AllowedStorageClassNames: []string{"standard", "premium-ssd"},
+				MaxGarbageCollectionTTL:  ptr.To(720 * time.Hour),
+				MinGarbageCollectionTTL:  ptr.To(time.Hour),
+				AllowedUpstreams:         []string{"*.example.com", "docker.io"},
+				DeniedUpstreams:          []string{"mirror.example.com"},
+			},
+		},
+		{
+			name: "invalid quantity",
+			data: map[string]string{PolicyKeyMaxVolumeSize: "100GB"},
+			errs: []string{`invalid validation policy: maxVolumeSize: invalid quantity "100GB"`},
+		},
+		{
+			name: "malformed upstream patterns",
+			data: map[string]string{
+				PolicyKeyAllowedUpstreams: "*.example.com,[",
+				PolicyKeyDeniedUpstreams:  "mirror[.example.com",
+			},
+			errs: []string{
+				`allowedUpstreams: invalid pattern "[": syntax error in pattern`,
+				`deniedUpstreams: invalid pattern "mirror[.example.com": syntax error in pattern`,
+			},
+		},
+		{
+			name: "invalid duration and unknown key reported together",
+			data: map[string]string{
+				PolicyKeyMaxGarbageCollectionTTL: "30d",
+				"maxVolumeSise":                  "100Gi",
+			},
+			errs: []string{
+				`maxGarbageCollectionTTL: invalid duration "30d"`,
+				"maxVolumeSise: unknown policy key",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := NewValidationPolicyFromMap(tt.data)
+
+			if len(tt.errs) > 0 {
+				for _, msg := range tt.errs {
+					require.ErrorContains(t, err, msg)
+				}
+
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, policy)
+		})
+	}
+}
+
+func TestNewValidatorWithPolicy(t *testing.T) {
+	policy, err := NewValidationPolicyFromMap(map[string]string{
+		PolicyKeyMaxVolumeSize:            "50Gi",
+		PolicyKeyAllowedStorageClassNames: "standard",
+		PolicyKeyMaxGarbageCollectionTTL:  "720h",
+	})
+	require.NoError(t, err)
+
+	config := &registrycache.RegistryCacheConfig{
+		Spec: registrycache.RegistryCacheConfigSpec{
+			Upstream: "docker.io",
+			Volume: &registrycache.Volume{
+				Size:             ptr.To(resource.MustParse("100Gi")),
+				StorageClassName: ptr.To("premium"),
+			},
+			GarbageCollection: &registrycache.GarbageCollection{
+				TTL: metav1.Duration{Duration: 1000 * time.Hour},
+			},
+		},
+	}
+
+	errs := NewValidatorWithPolicy(nil, nil, policy).Do(config)
+
+	requireErrorsMatch(t, field.ErrorList{
+		field.Invalid(field.NewPath("spec").Child("garbageCollection").Child("ttl"), 1000*time.Hour, "ttl 1000h exceeds the maximum allowed ttl 720h"),
+		field.Invalid(field.NewPath("spec").Child("volume").Child("size"), "100Gi", "requested size 100Gi exceeds the maximum allowed size 50Gi"),
+		field.NotSupported(field.NewPath("spec").Child("volume").Child("storageClassName"), "premium", []string{"standard"}),
+	}, errs)
+}
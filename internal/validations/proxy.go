@@ -0,0 +1,307 @@
+package validations
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+// ValidateProxy checks that the proxy urls are well-formed, use the scheme matching their field and carry no credentials
+func ValidateProxy(proxy *registrycache.Proxy, fldPath *field.Path) field.ErrorList {
+	return validateProxy(proxy, fldPath, ruleSet{})
+}
+
+// validateProxyAllowed rejects any proxy in air-gapped environments where outbound proxies are forbidden
+func (v Validator) validateProxyAllowed(proxy *registrycache.Proxy, fldPath *field.Path) field.ErrorList {
+	if proxy == nil || !v.options.AirGapped {
+		return nil
+	}
+
+	return v.rules().check(RuleProxyAirGapped, func() field.ErrorList {
+		return field.ErrorList{field.Forbidden(fldPath, "proxies are not allowed in an air-gapped environment, remove the proxy")}
+	})
+}
+
+func validateProxy(proxy *registrycache.Proxy, fldPath *field.Path, rules ruleSet) field.ErrorList {
+	if proxy == nil {
+		return nil
+	}
+
+	noURLs := ptr.Deref(proxy.HTTPProxy, "") == "" && ptr.Deref(proxy.HTTPSProxy, "") == ""
+
+	if errs := rules.check(RuleProxyRequired, func() field.ErrorList {
+		if noURLs {
+			return field.ErrorList{field.Required(fldPath, "at least one of httpProxy or httpsProxy must be set when proxy is specified")}
+		}
+
+		return nil
+	}); len(errs) > 0 || noURLs {
+		return errs
+	}
+
+	var errs field.ErrorList
+
+	if proxy.HTTPProxy != nil {
+		errs = append(errs, validateProxyURL(*proxy.HTTPProxy, "http", fldPath.Child("httpProxy"), rules)...)
+	}
+
+	if proxy.HTTPSProxy != nil {
+		errs = append(errs, validateProxyURL(*proxy.HTTPSProxy, "https", fldPath.Child("httpsProxy"), rules)...)
+	}
+
+	return errs
+}
+
+func validateProxyURL(proxyURL, expectedScheme string, fldPath *field.Path, rules ruleSet) field.ErrorList {
+	hasSeparator := strings.Contains(proxyURL, "://")
+	parsed, parseErr := url.Parse(proxyURL)
+	redacted := redactURL(proxyURL)
+
+	if errs := rules.check(RuleProxyURL, func() field.ErrorList {
+		// scheme-relative urls like //proxy.corp:3128 and bare host:port values parse into surprising schemes and hosts
+		if withoutScheme, found := strings.CutPrefix(redacted, "//"); found || !hasProxyScheme(proxyURL) {
+			withoutScheme = strings.TrimPrefix(withoutScheme, "://")
+			return field.ErrorList{field.Invalid(fldPath, redacted, fmt.Sprintf("url must start with an explicit http:// or https:// scheme, use %q instead", expectedScheme+"://"+withoutScheme))}
+		}
+
+		if !hasSeparator {
+			return field.ErrorList{field.Invalid(fldPath, redacted, "url is missing the '://' separator between scheme and host")}
+		}
+
+		if parseErr != nil {
+			return field.ErrorList{field.Invalid(fldPath, redacted, fmt.Sprintf("url cannot be parsed: %v", errors.Unwrap(parseErr)))}
+		}
+
+		return nil
+	}); len(errs) > 0 || !hasSeparator || parseErr != nil {
+		return errs
+	}
+
+	if errs := rules.check(RuleProxyScheme, func() field.ErrorList {
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return field.ErrorList{field.Invalid(fldPath, redacted, fmt.Sprintf("unsupported scheme %q, only http and https schemes are supported for a proxy", parsed.Scheme))}
+		}
+
+		if scheme := strings.ToLower(parsed.Scheme); scheme != expectedScheme {
+			return field.ErrorList{field.Invalid(fldPath, redacted, fmt.Sprintf("%s must use the %s scheme, got %s", fldPath.String(), expectedScheme, scheme))}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	if errs := rules.check(RuleProxyHost, func() field.ErrorList {
+		if parsed.Host == "" {
+			return field.ErrorList{field.Invalid(fldPath, redacted, "url must contain a host")}
+		}
+
+		if errs := validateHost(parsed.Hostname(), redacted, fldPath); len(errs) > 0 {
+			return errs
+		}
+
+		if port := parsed.Port(); port != "" {
+			return validatePortField(redacted, parsed.Hostname(), port, fldPath)
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	if errs := rules.check(RuleProxyCredentials, func() field.ErrorList {
+		if parsed.User != nil {
+			return field.ErrorList{field.Invalid(fldPath, redacted, "proxy url must not contain credentials, move them into the secret referenced by spec.secretReferenceName")}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	return rules.check(RuleProxyLoopback, func() field.ErrorList {
+		if isLoopbackHost(parsed.Hostname()) {
+			return field.ErrorList{field.Invalid(fldPath, redacted, fmt.Sprintf("%s points to a loopback address which is not reachable from the registry cache pod", fldPath.String()))}
+		}
+
+		return nil
+	})
+}
+
+// redactURL masks the password of a url the way url.URL.Redacted does, it also works on urls that do not parse so that
+// no error message leaks the credentials of a malformed proxy url
+func redactURL(rawURL string) string {
+	authorityStart := 0
+	if i := strings.Index(rawURL, "//"); i >= 0 {
+		authorityStart = i + 2
+	}
+
+	authority := rawURL[authorityStart:]
+	if i := strings.IndexAny(authority, "/?#"); i >= 0 {
+		authority = authority[:i]
+	}
+
+	at := strings.LastIndex(authority, "@")
+	if at < 0 {
+		return rawURL
+	}
+
+	username, _, hasPassword := strings.Cut(authority[:at], ":")
+	if !hasPassword {
+		return rawURL
+	}
+
+	return rawURL[:authorityStart] + username + ":xxxxx" + rawURL[authorityStart+at:]
+}
+
+// malformedProxySeparators are the prefixes of an http or https scheme with a mistyped separator
+var malformedProxySeparators = []string{"http:/", "http//", "https:/", "https//"}
+
+// hasProxyScheme also accepts a scheme with a malformed separator, e.g. http//proxy.corp, which gets a dedicated message,
+// hosts merely starting with http like httpbin.local:3128 have no scheme
+func hasProxyScheme(proxyURL string) bool {
+	scheme, _, found := strings.Cut(proxyURL, "://")
+	if found {
+		return scheme != ""
+	}
+
+	lower := strings.ToLower(proxyURL)
+
+	return slices.ContainsFunc(malformedProxySeparators, func(prefix string) bool { return strings.HasPrefix(lower, prefix) })
+}
+
+// validateProxyForbiddenCIDRs rejects proxies whose host is an IP literal inside one of the forbidden CIDRs, hostnames are not
+// resolved, malformed urls are skipped as validateProxy already reports them
+func (v Validator) validateProxyForbiddenCIDRs(proxy *registrycache.Proxy, fldPath *field.Path) field.ErrorList {
+	if proxy == nil || len(v.options.ForbiddenProxyCIDRs) == 0 {
+		return nil
+	}
+
+	return v.rules().check(RuleProxyForbiddenCIDR, func() field.ErrorList {
+		var errs field.ErrorList
+
+		if proxy.HTTPProxy != nil {
+			errs = append(errs, v.validateProxyURLForbiddenCIDRs(*proxy.HTTPProxy, fldPath.Child("httpProxy"))...)
+		}
+
+		if proxy.HTTPSProxy != nil {
+			errs = append(errs, v.validateProxyURLForbiddenCIDRs(*proxy.HTTPSProxy, fldPath.Child("httpsProxy"))...)
+		}
+
+		return errs
+	})
+}
+
+func (v Validator) validateProxyURLForbiddenCIDRs(proxyURL string, fldPath *field.Path) field.ErrorList {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil
+	}
+
+	addr, err := netip.ParseAddr(parsed.Hostname())
+	if err != nil {
+		return nil
+	}
+
+	addr = addr.WithZone("").Unmap()
+
+	for _, prefix := range v.options.ForbiddenProxyCIDRs {
+		if prefix.Contains(addr) {
+			return field.ErrorList{field.Forbidden(fldPath, fmt.Sprintf("proxy host %s is inside the forbidden CIDR %s", addr, prefix))}
+		}
+	}
+
+	return nil
+}
+
+// warnOnProxyWithoutPort flags valid proxy urls without a port, the scheme's default port is rarely the one a proxy listens on,
+// urls with errors are skipped so that the warning does not pile up on top of the error explaining what to fix
+func warnOnProxyWithoutPort(proxy *registrycache.Proxy, fldPath *field.Path, errs field.ErrorList) field.ErrorList {
+	if proxy == nil {
+		return nil
+	}
+
+	var warnings field.ErrorList
+
+	if proxy.HTTPProxy != nil && !hasErrorAt(errs, fldPath.Child("httpProxy")) {
+		warnings = append(warnings, warnOnProxyURLWithoutPort(*proxy.HTTPProxy, fldPath.Child("httpProxy"))...)
+	}
+
+	if proxy.HTTPSProxy != nil && !hasErrorAt(errs, fldPath.Child("httpsProxy")) {
+		warnings = append(warnings, warnOnProxyURLWithoutPort(*proxy.HTTPSProxy, fldPath.Child("httpsProxy"))...)
+	}
+
+	return warnings
+}
+
+func hasErrorAt(errs field.ErrorList, fldPath *field.Path) bool {
+	return slices.ContainsFunc(errs, func(err *field.Error) bool { return err.Field == fldPath.String() })
+}
+
+func warnOnProxyURLWithoutPort(proxyURL string, fldPath *field.Path) field.ErrorList {
+	if !strings.Contains(proxyURL, "://") {
+		return nil
+	}
+
+	parsed, err := url.Parse(proxyURL)
+	if err != nil || parsed.Host == "" || parsed.Port() != "" {
+		return nil
+	}
+
+	defaultPort, found := defaultPorts[strings.ToLower(parsed.Scheme)]
+	if !found {
+		return nil
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, parsed.Redacted(), fmt.Sprintf("proxy url has no port so %s is assumed, proxies usually listen on a port like 3128 or 8080, set the port explicitly", defaultPort))}
+}
+
+func isLoopbackHost(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(host)
+
+	return err == nil && addr.IsLoopback()
+}
+
+// warnOnProxyForInternalUpstream flags proxies configured for an upstream that looks cluster-internal,
+// such an upstream is usually not reachable through an external proxy
+func warnOnProxyForInternalUpstream(upstream string, proxy *registrycache.Proxy, fldPath *field.Path) field.ErrorList {
+	if proxy == nil || (ptr.Deref(proxy.HTTPProxy, "") == "" && ptr.Deref(proxy.HTTPSProxy, "") == "") {
+		return nil
+	}
+
+	if !isClusterInternalHost(upstreamHost(upstream)) {
+		return nil
+	}
+
+	var proxyURLs []string
+	for _, proxyURL := range []*string{proxy.HTTPProxy, proxy.HTTPSProxy} {
+		if ptr.Deref(proxyURL, "") != "" {
+			proxyURLs = append(proxyURLs, redactURL(*proxyURL))
+		}
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, proxyURLs, fmt.Sprintf("upstream %s looks cluster-internal but a proxy is configured, remove the proxy or use a non-internal upstream", upstream))}
+}
+
+func isClusterInternalHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	if strings.HasSuffix(host, ".svc") || strings.HasSuffix(host, ".svc.cluster.local") {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(host)
+
+	return err == nil && addr.IsPrivate()
+}
@@ -0,0 +1,298 @@
+package validations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// rootPath stands for the whole document in errors that cannot be attributed to a field
+var rootPath = field.NewPath("<root>")
+
+// DoRaw decodes a JSON encoded config and validates it, fields unknown to the API are reported with their full path
+// which is why they are collected by walking the document rather than relying on json.Decoder.DisallowUnknownFields
+// that stops at the first one and does not say where it was found
+func (v Validator) DoRaw(raw []byte) field.ErrorList {
+	var document any
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return field.ErrorList{invalidJSONError(raw, err)}
+	}
+
+	configType := reflect.TypeOf(registrycache.RegistryCacheConfig{})
+	errs := unknownFields(document, configType, nil)
+
+	// types such as resource.Quantity or metav1.Duration report a malformed value without the field it belongs to,
+	// so every value is decoded on its own first
+	conversionErrs := conversionErrors(document, configType, nil)
+	if ttlErr := garbageCollectionTTLError(document); ttlErr != nil {
+		conversionErrs = slices.DeleteFunc(conversionErrs, func(err *field.Error) bool { return err.Field == ttlErr.Field })
+		conversionErrs = append(conversionErrs, ttlErr)
+	}
+
+	if len(conversionErrs) > 0 {
+		return append(errs, conversionErrs...)
+	}
+
+	var config registrycache.RegistryCacheConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return append(errs, field.InternalError(rootPath, fmt.Errorf("config cannot be decoded: %w", err)))
+	}
+
+	return append(errs, v.Do(&config)...)
+}
+
+// DoUnstructured validates a config held as unstructured content, it goes through DoRaw so that unknown fields and
+// values of the wrong type are reported at their path instead of as an opaque conversion error
+func (v Validator) DoUnstructured(u *unstructured.Unstructured) field.ErrorList {
+	raw, err := json.Marshal(u.UnstructuredContent())
+	if err != nil {
+		return field.ErrorList{field.InternalError(rootPath, fmt.Errorf("config cannot be encoded: %w", err))}
+	}
+
+	return v.DoRaw(raw)
+}
+
+// invalidJSONError only quotes the few bytes before a syntax error instead of echoing the whole document
+func invalidJSONError(raw []byte, err error) *field.Error {
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return field.Invalid(rootPath, "", fmt.Sprintf("config is not valid JSON: %v", err))
+	}
+
+	offset := int(min(syntaxErr.Offset, int64(len(raw))))
+	excerpt := string(raw[max(0, offset-jsonExcerptLength):offset])
+
+	return field.Invalid(rootPath, excerpt, fmt.Sprintf("config is not valid JSON at offset %d: %v", offset, err))
+}
+
+const jsonExcerptLength = 20
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// conversionErrors descends into structs and slices and decodes every other value, including the values of types with
+// their own JSON decoding, on its own so that a failure is reported at its path
+func conversionErrors(value any, t reflect.Type, fldPath *field.Path) field.ErrorList {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	customDecoding := reflect.PointerTo(t).Implements(jsonUnmarshalerType)
+
+	switch {
+	case t.Kind() == reflect.Struct && !customDecoding:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return conversionError(value, t, fldPath)
+		}
+
+		known := jsonFields(t)
+
+		var errs field.ErrorList
+
+		for _, name := range slices.Sorted(maps.Keys(object)) {
+			if fieldType, found := known[name]; found {
+				errs = append(errs, conversionErrors(object[name], fieldType, childPath(fldPath, name))...)
+			}
+		}
+
+		return errs
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 && !customDecoding:
+		elements, ok := value.([]any)
+		if !ok {
+			return conversionError(value, t, fldPath)
+		}
+
+		var errs field.ErrorList
+
+		for i, element := range elements {
+			errs = append(errs, conversionErrors(element, t.Elem(), fldPath.Index(i))...)
+		}
+
+		return errs
+	default:
+		return conversionError(value, t, fldPath)
+	}
+}
+
+func conversionError(value any, t reflect.Type, fldPath *field.Path) field.ErrorList {
+	if fldPath == nil {
+		fldPath = rootPath
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	err = json.Unmarshal(data, reflect.New(t).Interface())
+	if err == nil {
+		return nil
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return field.ErrorList{field.Invalid(fldPath, typeErr.Value, fmt.Sprintf("expected %s", typeErr.Type))}
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, value, err.Error())}
+}
+
+var humanDurationRegexp = regexp.MustCompile(`^\s*(\d+(?:\.\d+)?)\s*([a-zA-Z]+)\s*$`)
+
+var humanDurationUnits = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+	"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+}
+
+func garbageCollectionTTLError(document any) *field.Error {
+	object, _ := document.(map[string]any)
+	spec, _ := object["spec"].(map[string]any)
+	gc, _ := spec["garbageCollection"].(map[string]any)
+
+	ttl, ok := gc["ttl"].(string)
+	if !ok {
+		return nil
+	}
+
+	if _, err := time.ParseDuration(ttl); err == nil {
+		return nil
+	}
+
+	detail := "ttl must be a Go duration such as 720h or 30m"
+	if suggestion, found := suggestDuration(ttl); found {
+		detail = fmt.Sprintf("%s, use %q instead", detail, suggestion)
+	}
+
+	return field.Invalid(field.NewPath("spec", "garbageCollection", "ttl"), ttl, detail)
+}
+
+// suggestDuration translates a single amount with a spelled out or day based unit, e.g. 2 days, into a Go duration
+func suggestDuration(value string) (string, bool) {
+	match := humanDurationRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return "", false
+	}
+
+	unit, found := humanDurationUnits[strings.ToLower(match[2])]
+	if !found {
+		return "", false
+	}
+
+	amount, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return "", false
+	}
+
+	return formatDuration(time.Duration(amount * float64(unit))), true
+}
+
+func unknownFields(value any, t reflect.Type, fldPath *field.Path) field.ErrorList {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	// types with their own decoding such as metav1.FieldsV1 do not describe their keys with struct fields
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		// types such as resource.Quantity or metav1.Duration are structs encoded as plain values
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		known := jsonFields(t)
+
+		var errs field.ErrorList
+
+		for _, name := range slices.Sorted(maps.Keys(object)) {
+			childPath := childPath(fldPath, name)
+
+			fieldType, found := known[name]
+			if !found {
+				errs = append(errs, field.Invalid(childPath, name, "unknown field"))
+				continue
+			}
+
+			errs = append(errs, unknownFields(object[name], fieldType, childPath)...)
+		}
+
+		return errs
+	case reflect.Slice:
+		elements, ok := value.([]any)
+		if !ok || fldPath == nil {
+			return nil
+		}
+
+		var errs field.ErrorList
+
+		for i, element := range elements {
+			errs = append(errs, unknownFields(element, t.Elem(), fldPath.Index(i))...)
+		}
+
+		return errs
+	default:
+		return nil
+	}
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(structField.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && (structField.Anonymous || strings.Contains(opts, "inline")) {
+			for embeddedName, embeddedType := range jsonFields(structField.Type) {
+				fields[embeddedName] = embeddedType
+			}
+
+			continue
+		}
+
+		if name == "" {
+			name = structField.Name
+		}
+
+		fields[name] = structField.Type
+	}
+
+	return fields
+}
+
+func childPath(fldPath *field.Path, name string) *field.Path {
+	if fldPath == nil {
+		return field.NewPath(name)
+	}
+
+	return fldPath.Child(name)
+}
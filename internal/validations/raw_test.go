@@ -0,0 +1,194 @@
+package validations
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestDoRaw(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		raw        string
+		errorsList field.ErrorList
+	}{
+		{
+			name:       "valid config",
+			raw:        `{"metadata":{"name":"docker","namespace":"default"},"spec":{"upstream":"docker.io","volume":{"size":"10Gi"},"garbageCollection":{"ttl":"168h"}}}`,
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "unknown spec field",
+			raw:  `{"spec":{"upstrem":"docker.io","volume":{"size":"10Gi","storageClass":"standard"}}}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "upstrem"), "upstrem", "unknown field"),
+				field.Invalid(field.NewPath("spec", "volume", "storageClass"), "storageClass", "unknown field"),
+				field.Required(field.NewPath("spec", "upstream"), "upstream must be provided"),
+			},
+		},
+		{
+			name:       "managed fields are not reported as unknown",
+			raw:        `{"metadata":{"name":"docker","managedFields":[{"manager":"kubectl","operation":"Apply","fieldsType":"FieldsV1","fieldsV1":{"f:spec":{"f:upstream":{}}}}]},"spec":{"upstream":"docker.io","volume":{"size":"10Gi"}}}`,
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "unknown top level field",
+			raw:  `{"specs":{"upstream":"docker.io"}}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("specs"), "specs", "unknown field"),
+				field.Required(field.NewPath("spec"), "spec cannot be empty"),
+			},
+		},
+		{
+			name: "field with wrong type",
+			raw:  `{"spec":{"upstream":5}}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "upstream"), "number", "expected string"),
+			},
+		},
+		{
+			name: "ttl spelled out in days",
+			raw:  `{"spec":{"upstream":"docker.io","garbageCollection":{"ttl":"2 days"}}}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "garbageCollection", "ttl"), "2 days", `ttl must be a Go duration such as 720h or 30m, use "48h" instead`),
+			},
+		},
+		{
+			name: "ttl that cannot be translated",
+			raw:  `{"spec":{"upstream":"docker.io","garbageCollection":{"ttl":"forever"}}}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "garbageCollection", "ttl"), "forever", "ttl must be a Go duration such as 720h or 30m"),
+			},
+		},
+		{
+			name: "malformed quantity",
+			raw:  `{"spec":{"upstream":"docker.io","volume":{"size":"abc"}}}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "volume", "size"), "abc", "quantities must match the regular expression"),
+			},
+		},
+		{
+			name: "malformed quantity and ttl reported together",
+			raw:  `{"spec":{"upstream":"docker.io","volume":{"size":"10 gigs"},"garbageCollection":{"ttl":"2 days"}}}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "garbageCollection", "ttl"), "2 days", `use "48h" instead`),
+				field.Invalid(field.NewPath("spec", "volume", "size"), "10 gigs", "quantities must match the regular expression"),
+			},
+		},
+		{
+			name: "spec with wrong type",
+			raw:  `{"spec":"docker.io"}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec"), "string", "expected v1beta1.RegistryCacheConfigSpec"),
+			},
+		},
+		{
+			name: "invalid JSON",
+			raw:  `{"metadata":{"name":"docker"},"spec":{"upstream":"docker.io",}}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("<root>"), `tream":"docker.io",}`, "config is not valid JSON at offset 62"),
+			},
+		},
+		{
+			name: "semantic errors are reported",
+			raw:  `{"spec":{"upstream":"docker.io:77777"}}`,
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "upstream"), "docker.io:77777", "valid port must be in the range [1, 65535]"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requireErrorsMatch(t, tt.errorsList, NewValidator(nil, nil).DoRaw([]byte(tt.raw)))
+		})
+	}
+}
+
+func TestDoUnstructured(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		object     map[string]any
+		errorsList field.ErrorList
+	}{
+		{
+			name: "valid config",
+			object: map[string]any{
+				"apiVersion": "core.kyma-project.io/v1beta1",
+				"kind":       "RegistryCacheConfig",
+				"metadata":   map[string]any{"name": "docker", "namespace": "default"},
+				"spec":       map[string]any{"upstream": "docker.io", "volume": map[string]any{"size": "10Gi"}},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "config read from the API server",
+			object: map[string]any{
+				"apiVersion": "core.kyma-project.io/v1beta1",
+				"kind":       "RegistryCacheConfig",
+				"metadata": map[string]any{
+					"name":              "docker",
+					"namespace":         "default",
+					"uid":               "0b2f8c64-4c4a-4d55-9f0e-3c1f7d9e6a21",
+					"resourceVersion":   "123456",
+					"generation":        int64(2),
+					"creationTimestamp": "2024-05-06T07:08:09Z",
+					"managedFields": []any{
+						map[string]any{
+							"apiVersion": "core.kyma-project.io/v1beta1",
+							"fieldsType": "FieldsV1",
+							"fieldsV1": map[string]any{
+								"f:spec": map[string]any{
+									".":          map[string]any{},
+									"f:upstream": map[string]any{},
+									"f:volume": map[string]any{
+										".":      map[string]any{},
+										"f:size": map[string]any{},
+									},
+								},
+							},
+							"manager":   "kubectl-client-side-apply",
+							"operation": "Update",
+							"time":      "2024-05-06T07:08:09Z",
+						},
+					},
+				},
+				"spec": map[string]any{"upstream": "docker.io", "volume": map[string]any{"size": "10Gi"}},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "field with wrong type",
+			object: map[string]any{
+				"spec": map[string]any{"upstream": "docker.io", "volume": map[string]any{"storageClassName": int64(5)}},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "volume", "storageClassName"), "number", "expected string"),
+			},
+		},
+		{
+			name: "malformed quantity",
+			object: map[string]any{
+				"spec": map[string]any{"upstream": "docker.io", "volume": map[string]any{"size": "abc"}},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "volume", "size"), "abc", "quantities must match the regular expression"),
+			},
+		},
+		{
+			name: "unknown field and semantic error",
+			object: map[string]any{
+				"spec": map[string]any{"upstream": "docker.io:77777", "mirror": "quay.io"},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec", "mirror"), "mirror", "unknown field"),
+				field.Invalid(field.NewPath("spec", "upstream"), "docker.io:77777", "valid port must be in the range [1, 65535]"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidator(nil, nil).DoUnstructured(&unstructured.Unstructured{Object: tt.object})
+
+			requireErrorsMatch(t, tt.errorsList, errs)
+		})
+	}
+}
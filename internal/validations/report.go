@@ -0,0 +1,45 @@
+package validations
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Report is a JSON friendly representation of validation results that does not expose apimachinery types
+type Report struct {
+	Valid  bool          `json:"valid"`
+	Errors []ReportError `json:"errors,omitempty"`
+}
+
+type ReportError struct {
+	Field    string `json:"field"`
+	Type     string `json:"type"`
+	BadValue any    `json:"badValue,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+func ToReport(errs field.ErrorList) Report {
+	report := Report{
+		Valid: len(errs) == 0,
+	}
+
+	for _, err := range errs {
+		report.Errors = append(report.Errors, ReportError{
+			Field:    err.Field,
+			Type:     string(err.Type),
+			BadValue: err.BadValue,
+			Detail:   err.Detail,
+		})
+	}
+
+	return report
+}
+
+// SummarizeErrors counts the errors per type, e.g. to export them as metrics, an empty list yields an empty map
+func SummarizeErrors(errs field.ErrorList) map[field.ErrorType]int {
+	summary := make(map[field.ErrorType]int)
+	for _, err := range errs {
+		summary[err.Type]++
+	}
+
+	return summary
+}
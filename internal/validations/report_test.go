@@ -0,0 +1,68 @@
+package validations
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestToReport(t *testing.T) {
+	t.Run("no errors", func(t *testing.T) {
+		report := ToReport(nil)
+
+		require.True(t, report.Valid)
+		require.Empty(t, report.Errors)
+	})
+
+	t.Run("errors round-trip through json", func(t *testing.T) {
+		upstreamPath := field.NewPath("spec").Child("upstream")
+		secretPath := field.NewPath("spec").Child("secretReferenceName")
+
+		report := ToReport(field.ErrorList{
+			field.Invalid(upstreamPath, "docker.io:77777", "valid port must be in the range [1, 65535]"),
+			field.NotFound(secretPath, "my-secret"),
+		})
+
+		data, err := json.Marshal(report)
+		require.NoError(t, err)
+
+		var decoded Report
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		require.Equal(t, Report{
+			Valid: false,
+			Errors: []ReportError{
+				{
+					Field:    "spec.upstream",
+					Type:     string(field.ErrorTypeInvalid),
+					BadValue: "docker.io:77777",
+					Detail:   "valid port must be in the range [1, 65535]",
+				},
+				{
+					Field:    "spec.secretReferenceName",
+					Type:     string(field.ErrorTypeNotFound),
+					BadValue: "my-secret",
+				},
+			},
+		}, decoded)
+	})
+}
+
+func TestSummarizeErrors(t *testing.T) {
+	upstreamPath := field.NewPath("spec").Child("upstream")
+
+	require.Equal(t, map[field.ErrorType]int{}, SummarizeErrors(nil))
+
+	require.Equal(t, map[field.ErrorType]int{
+		field.ErrorTypeInvalid:  2,
+		field.ErrorTypeNotFound: 1,
+		field.ErrorTypeRequired: 1,
+	}, SummarizeErrors(field.ErrorList{
+		field.Invalid(upstreamPath, "docker.io:77777", "valid port must be in the range [1, 65535]"),
+		field.Invalid(field.NewPath("spec").Child("remoteURL"), "docker.io", "url must start with 'http://' or 'https://'"),
+		field.NotFound(field.NewPath("spec").Child("secretReferenceName"), "my-secret"),
+		field.Required(field.NewPath("metadata").Child("namespace"), "namespace must be set"),
+	}))
+}
@@ -0,0 +1,37 @@
+package validations
+
+import (
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// RuleError is a validation error tagged with the ID of the rule that produced it, the ID is empty for errors
+// that no rule produces, such as an empty spec or a failed secret lookup
+type RuleError struct {
+	*field.Error
+	RuleID string
+}
+
+// DoWithRules returns the same errors as Do, each tagged with its rule ID so that failures can be aggregated by rule
+func (v Validator) DoWithRules(newConfig *registrycache.RegistryCacheConfig) []RuleError {
+	v.recorder = newRuleRecorder()
+
+	errs := v.Do(newConfig)
+
+	ruleErrs := make([]RuleError, 0, len(errs))
+	for _, err := range errs {
+		ruleErrs = append(ruleErrs, RuleError{Error: err, RuleID: v.recorder.ruleOf(err)})
+	}
+
+	return ruleErrs
+}
+
+// ToErrorList drops the rule IDs for callers that only need the plain errors
+func ToErrorList(ruleErrs []RuleError) field.ErrorList {
+	var errs field.ErrorList
+	for _, ruleErr := range ruleErrs {
+		errs = append(errs, ruleErr.Error)
+	}
+
+	return errs
+}
@@ -0,0 +1,56 @@
+package validations
+
+import (
+	"testing"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestDoWithRules(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		registrycache.RegistryCacheConfig
+		ruleIDs []string
+	}{
+		{
+			name: "valid config",
+			RegistryCacheConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+				},
+			},
+			ruleIDs: []string{},
+		},
+		{
+			name: "errors of several rules",
+			RegistryCacheConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream:  InvalidUpstreamPort,
+					RemoteURL: ptr.To(InvalidRemoteURL),
+					Proxy: &registrycache.Proxy{
+						HTTPProxy: ptr.To("ftp://proxy.corp:3128"),
+					},
+				},
+			},
+			ruleIDs: []string{RuleProxyScheme, RuleRemoteURLScheme, RuleUpstreamPort},
+		},
+		{
+			name:    "error not produced by a rule",
+			ruleIDs: []string{""},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleErrs := NewValidator(nil, nil).DoWithRules(&tt.RegistryCacheConfig)
+
+			ruleIDs := []string{}
+			for _, ruleErr := range ruleErrs {
+				ruleIDs = append(ruleIDs, ruleErr.RuleID)
+			}
+
+			require.Equal(t, tt.ruleIDs, ruleIDs)
+			require.Equal(t, NewValidator(nil, nil).Do(&tt.RegistryCacheConfig), ToErrorList(ruleErrs))
+		})
+	}
+}
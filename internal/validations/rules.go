@@ -0,0 +1,272 @@
+package validations
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Rule IDs identify the individual checks, they are stable so that they can be referenced in ValidationOptions.DisabledRules
+const (
+	RuleUpstreamRequired      = "upstream.required"
+	RuleUpstreamUnixSocket    = "upstream.unixSocket"
+	RuleUpstreamScheme        = "upstream.scheme"
+	RuleUpstreamQuery         = "upstream.query"
+	RuleUpstreamPath          = "upstream.path"
+	RuleUpstreamHost          = "upstream.host"
+	RuleUpstreamPort          = "upstream.port"
+	RuleUpstreamUnique        = "upstream.unique"
+	RuleUpstreamPolicy        = "upstream.policy"
+	RuleUpstreamPrivate       = "upstream.private"
+	RuleUpstreamDefaultPort   = "upstream.defaultPort"
+	RuleUpstreamSelfLoop      = "upstream.selfLoop"
+	RuleUpstreamWellKnownPort = "upstream.wellKnownPort"
+	RuleUpstreamIPLiteral     = "upstream.ipLiteral"
+
+	RuleRemoteURLScheme = "remoteURL.scheme"
+
+	RuleVolumeSizePositive      = "volume.size.positive"
+	RuleVolumeSizeMax           = "volume.size.max"
+	RuleVolumeSizeUnset         = "volume.size.unset"
+	RuleVolumeSizeDecimalSuffix = "volume.size.decimalSuffix"
+	RuleVolumeSizeGranularity   = "volume.size.granularity"
+	RuleVolumeSizeShrink        = "volume.size.shrink"
+
+	RuleStorageClassNameFormat    = "storageClassName.format"
+	RuleStorageClassNameAllowed   = "storageClassName.allowed"
+	RuleStorageClassNameExists    = "storageClassName.exists"
+	RuleStorageClassNameImmutable = "storageClassName.immutable"
+
+	RuleGarbageCollectionTTLNonNegative = "garbageCollection.ttl.nonNegative"
+	RuleGarbageCollectionTTLMax         = "garbageCollection.ttl.max"
+	RuleGarbageCollectionTTLMin         = "garbageCollection.ttl.min"
+	RuleGarbageCollectionTTLSmallVolume = "garbageCollection.ttl.smallVolume"
+	RuleGarbageCollectionTTLWholeHours  = "garbageCollection.ttl.wholeHours"
+	RuleGarbageCollectionUnset          = "garbageCollection.unset"
+
+	RuleProxyAirGapped        = "proxy.airGapped"
+	RuleProxyRequired         = "proxy.required"
+	RuleProxyURL              = "proxy.url"
+	RuleProxyScheme           = "proxy.scheme"
+	RuleProxyHost             = "proxy.host"
+	RuleProxyCredentials      = "proxy.credentials"
+	RuleProxyLoopback         = "proxy.loopback"
+	RuleProxyForbiddenCIDR    = "proxy.forbiddenCIDR"
+	RuleProxyInternalUpstream = "proxy.internalUpstream"
+	RuleProxyDefaultPort      = "proxy.defaultPort"
+
+	RuleSecretRequired         = "secret.required"
+	RuleSecretName             = "secret.name"
+	RuleSecretNamespace        = "secret.namespace"
+	RuleSecretExists           = "secret.exists"
+	RuleSecretImmutable        = "secret.immutable"
+	RuleSecretType             = "secret.type"
+	RuleSecretKeyCasing        = "secret.keyCasing"
+	RuleSecretStructure        = "secret.structure"
+	RuleSecretDockerConfigJSON = "secret.dockerConfigJSON"
+	RuleSecretCACertificate    = "secret.caCertificate"
+	RuleSecretSize             = "secret.size"
+
+	RuleUpstreamImmutable = "upstream.immutable"
+
+	RuleNamespaceLimit         = "namespace.limit"
+	RuleNamespaceStorageBudget = "namespace.storageBudget"
+
+	RuleEmptyOptionalField = "spec.emptyOptionalField"
+)
+
+var allRules = []string{
+	RuleUpstreamRequired,
+	RuleUpstreamUnixSocket,
+	RuleUpstreamScheme,
+	RuleUpstreamQuery,
+	RuleUpstreamPath,
+	RuleUpstreamHost,
+	RuleUpstreamPort,
+	RuleUpstreamUnique,
+	RuleUpstreamPolicy,
+	RuleUpstreamPrivate,
+	RuleUpstreamDefaultPort,
+	RuleUpstreamSelfLoop,
+	RuleUpstreamWellKnownPort,
+	RuleUpstreamIPLiteral,
+	RuleRemoteURLScheme,
+	RuleVolumeSizePositive,
+	RuleVolumeSizeMax,
+	RuleVolumeSizeUnset,
+	RuleVolumeSizeDecimalSuffix,
+	RuleVolumeSizeGranularity,
+	RuleVolumeSizeShrink,
+	RuleStorageClassNameFormat,
+	RuleStorageClassNameAllowed,
+	RuleStorageClassNameExists,
+	RuleStorageClassNameImmutable,
+	RuleGarbageCollectionTTLNonNegative,
+	RuleGarbageCollectionTTLMax,
+	RuleGarbageCollectionTTLMin,
+	RuleGarbageCollectionTTLSmallVolume,
+	RuleGarbageCollectionTTLWholeHours,
+	RuleGarbageCollectionUnset,
+	RuleProxyAirGapped,
+	RuleProxyRequired,
+	RuleProxyURL,
+	RuleProxyScheme,
+	RuleProxyHost,
+	RuleProxyCredentials,
+	RuleProxyLoopback,
+	RuleProxyForbiddenCIDR,
+	RuleProxyInternalUpstream,
+	RuleProxyDefaultPort,
+	RuleSecretRequired,
+	RuleSecretName,
+	RuleSecretNamespace,
+	RuleSecretExists,
+	RuleSecretImmutable,
+	RuleSecretType,
+	RuleSecretKeyCasing,
+	RuleSecretStructure,
+	RuleSecretDockerConfigJSON,
+	RuleSecretCACertificate,
+	RuleSecretSize,
+	RuleUpstreamImmutable,
+	RuleNamespaceLimit,
+	RuleNamespaceStorageBudget,
+	RuleEmptyOptionalField,
+}
+
+// Rules returns the sorted IDs of all known validation rules
+func Rules() []string {
+	rules := slices.Clone(allRules)
+	slices.Sort(rules)
+
+	return rules
+}
+
+// ruleSet runs the checks of the enabled rules, the zero value enables every rule and does not log
+type ruleSet struct {
+	disabled map[string]bool
+	warnOnly map[string]bool
+	logger   logr.Logger
+	recorder *ruleRecorder
+	demoted  *demotedErrors
+}
+
+func (r ruleSet) check(rule string, fn func() field.ErrorList) field.ErrorList {
+	errs, demoted := r.checkWithWarnings(rule, func() (field.ErrorList, field.ErrorList) {
+		return fn(), nil
+	})
+	r.demoted.add(demoted)
+
+	return errs
+}
+
+// checkWithWarnings runs a rule that can report errors and warnings at once, the rule only fails on errors
+func (r ruleSet) checkWithWarnings(rule string, fn func() (field.ErrorList, field.ErrorList)) (field.ErrorList, field.ErrorList) {
+	if r.disabled[rule] {
+		r.log(rule, "skip")
+		return nil, nil
+	}
+
+	// the rule is only timed with a logger so that the path without one stays allocation-free
+	logging := r.logger.GetSink() != nil
+
+	var start time.Time
+	if logging {
+		start = time.Now()
+	}
+
+	errs, warnings := fn()
+
+	if logging {
+		r.log(rule, outcome(errs), "duration", time.Since(start))
+	}
+
+	r.recorder.record(rule, errs)
+
+	if r.warnOnly[rule] {
+		return nil, append(warnings, errs...)
+	}
+
+	return errs, warnings
+}
+
+// log returns early without a logger so that the key-value arguments are not allocated
+func (r ruleSet) log(rule, outcome string, keysAndValues ...any) {
+	if r.logger.GetSink() == nil {
+		return
+	}
+
+	r.logger.V(2).Info("Validation rule evaluated", append([]any{"rule", rule, "outcome", outcome}, keysAndValues...)...)
+}
+
+func outcome(errs field.ErrorList) string {
+	if len(errs) > 0 {
+		return "fail"
+	}
+
+	return "pass"
+}
+
+func (v Validator) rules() ruleSet {
+	return ruleSet{
+		disabled: v.options.DisabledRules,
+		warnOnly: v.options.WarnOnlyRules,
+		logger:   v.options.Logger,
+		recorder: v.recorder,
+		demoted:  v.demoted,
+	}
+}
+
+// demotedErrors collects the errors of warn-only rules so that they can be reported as warnings, entry points
+// without warnings drop them since warn-only rules must never block
+type demotedErrors struct {
+	mu   sync.Mutex
+	errs field.ErrorList
+}
+
+func (d *demotedErrors) add(errs field.ErrorList) {
+	if d == nil || len(errs) == 0 {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.errs = append(d.errs, errs...)
+}
+
+// ruleRecorder remembers the rule that produced each error, it is shared by the concurrently validated field groups
+type ruleRecorder struct {
+	mu    sync.Mutex
+	rules map[*field.Error]string
+}
+
+func newRuleRecorder() *ruleRecorder {
+	return &ruleRecorder{rules: make(map[*field.Error]string)}
+}
+
+// record keeps the first rule seen for an error, which is the innermost one when checks are nested
+func (r *ruleRecorder) record(rule string, errs field.ErrorList) {
+	if r == nil || len(errs) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, err := range errs {
+		if _, found := r.rules[err]; !found {
+			r.rules[err] = rule
+		}
+	}
+}
+
+func (r *ruleRecorder) ruleOf(err *field.Error) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.rules[err]
+}
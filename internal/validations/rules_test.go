@@ -0,0 +1,242 @@
+package validations
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+func TestRules(t *testing.T) {
+	rules := Rules()
+
+	require.True(t, slices.IsSorted(rules))
+	require.Equal(t, len(rules), len(slices.Compact(slices.Clone(rules))))
+	require.Contains(t, rules, RuleUpstreamPort)
+	require.Contains(t, rules, RuleStorageClassNameExists)
+
+	rules[0] = "modified"
+	require.NotEqual(t, "modified", Rules()[0])
+}
+
+func TestDisabledRules(t *testing.T) {
+	mutableSecret := v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mutable-secret",
+			Namespace: "default",
+		},
+		Data: map[string][]byte{
+			"username": []byte("dXNlcg=="),
+			"password": []byte("cGFzc3dvcmQ="),
+		},
+	}
+
+	for _, tt := range []struct {
+		name string
+		registrycache.RegistryCacheConfig
+		secrets       []v1.Secret
+		options       ValidationOptions
+		disabledRules map[string]bool
+		errorsList    field.ErrorList
+	}{
+		{
+			name: "upstream port rule disabled",
+			RegistryCacheConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream:  InvalidUpstreamPort,
+					RemoteURL: ptr.To(InvalidRemoteURL),
+				},
+			},
+			disabledRules: map[string]bool{RuleUpstreamPort: true},
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec").Child("remoteURL"), InvalidRemoteURL, "url must start with 'http://' or 'https://'"),
+			},
+		},
+		{
+			name: "storage class existence rule disabled",
+			RegistryCacheConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						StorageClassName: ptr.To("premium"),
+					},
+				},
+			},
+			options: ValidationOptions{
+				StorageClassLister: fakeStorageClassLister{err: errors.New("lister must not be called")},
+			},
+			disabledRules: map[string]bool{RuleStorageClassNameExists: true},
+			errorsList:    field.ErrorList{},
+		},
+		{
+			name: "volume size rule disabled",
+			RegistryCacheConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("0")),
+					},
+					GarbageCollection: &registrycache.GarbageCollection{
+						TTL: metav1.Duration{Duration: -1},
+					},
+				},
+			},
+			disabledRules: map[string]bool{RuleVolumeSizePositive: true},
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec").Child("garbageCollection").Child("ttl"), time.Duration(-1), "ttl must be a non-negative duration"),
+			},
+		},
+		{
+			name: "proxy scheme rule disabled",
+			RegistryCacheConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Proxy: &registrycache.Proxy{
+						HTTPProxy:  ptr.To("https://proxy.corp:3128"),
+						HTTPSProxy: ptr.To("http://proxy.corp:3128"),
+					},
+				},
+			},
+			disabledRules: map[string]bool{RuleProxyScheme: true},
+			errorsList:    field.ErrorList{},
+		},
+		{
+			name:    "secret immutability rule disabled",
+			secrets: []v1.Secret{mutableSecret},
+			RegistryCacheConfig: registrycache.RegistryCacheConfig{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "default",
+				},
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream:            "docker.io",
+					SecretReferenceName: ptr.To(mutableSecret.Name),
+				},
+			},
+			disabledRules: map[string]bool{RuleSecretImmutable: true},
+			errorsList:    field.ErrorList{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			options := tt.options
+			options.DisabledRules = tt.disabledRules
+
+			errs := NewValidatorWithOptions(tt.secrets, nil, options).Do(&tt.RegistryCacheConfig)
+
+			requireErrorsMatch(t, tt.errorsList, errs)
+		})
+	}
+}
+
+func TestWarnOnlyRules(t *testing.T) {
+	config := &registrycache.RegistryCacheConfig{
+		Spec: registrycache.RegistryCacheConfigSpec{
+			Upstream:  "docker.io",
+			RemoteURL: ptr.To(InvalidRemoteURL),
+			Volume: &registrycache.Volume{
+				Size:             ptr.To(resource.MustParse("10Gi")),
+				StorageClassName: ptr.To("premium"),
+			},
+			GarbageCollection: &registrycache.GarbageCollection{
+				TTL: metav1.Duration{Duration: 168 * time.Hour},
+			},
+		},
+	}
+	storageClassNamePath := field.NewPath("spec").Child("volume").Child("storageClassName")
+	remoteURLErr := field.Invalid(field.NewPath("spec").Child("remoteURL"), InvalidRemoteURL, "url must start with 'http://' or 'https://'")
+
+	for _, tt := range []struct {
+		name          string
+		warnOnlyRules map[string]bool
+		disabledRules map[string]bool
+		errorsList    field.ErrorList
+		warningsList  field.ErrorList
+	}{
+		{
+			name:         "no warn-only rules",
+			errorsList:   field.ErrorList{remoteURLErr, field.NotSupported(storageClassNamePath, "premium", []string{"standard"})},
+			warningsList: field.ErrorList{},
+		},
+		{
+			name:          "storage class rule warn-only",
+			warnOnlyRules: map[string]bool{RuleStorageClassNameAllowed: true},
+			errorsList:    field.ErrorList{remoteURLErr},
+			warningsList:  field.ErrorList{field.NotSupported(storageClassNamePath, "premium", []string{"standard"})},
+		},
+		{
+			name:          "disabled wins over warn-only",
+			warnOnlyRules: map[string]bool{RuleStorageClassNameAllowed: true},
+			disabledRules: map[string]bool{RuleStorageClassNameAllowed: true},
+			errorsList:    field.ErrorList{remoteURLErr},
+			warningsList:  field.ErrorList{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewValidatorWithOptions(nil, nil, ValidationOptions{
+				AllowedStorageClassNames: []string{"standard"},
+				WarnOnlyRules:            tt.warnOnlyRules,
+				DisabledRules:            tt.disabledRules,
+			})
+
+			result := validator.DoDetailed(config)
+
+			requireErrorsMatch(t, tt.errorsList, result.Errors)
+			requireErrorsMatch(t, tt.warningsList, result.Warnings)
+			requireErrorsMatch(t, tt.errorsList, validator.Do(config))
+		})
+	}
+}
+
+func TestRuleLogging(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	logger := funcr.New(func(_, args string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		lines = append(lines, args)
+	}, funcr.Options{Verbosity: 2})
+
+	config := registrycache.RegistryCacheConfig{
+		Spec: registrycache.RegistryCacheConfigSpec{
+			Upstream:  InvalidUpstreamPort,
+			RemoteURL: ptr.To("https://registry-1.docker.io"),
+		},
+	}
+
+	NewValidatorWithOptions(nil, nil, ValidationOptions{
+		DisabledRules: map[string]bool{RuleUpstreamUnique: true},
+		Logger:        logger,
+	}).Do(&config)
+
+	requireLogged := func(prefix string) {
+		t.Helper()
+		require.True(t, slices.ContainsFunc(lines, func(line string) bool { return strings.HasPrefix(line, prefix) }), "no line starting with %s in %v", prefix, lines)
+	}
+
+	requireLogged(`"level"=2 "msg"="Validation rule evaluated" "rule"="upstream.port" "outcome"="fail" "duration"=`)
+	requireLogged(`"level"=2 "msg"="Validation rule evaluated" "rule"="remoteURL.scheme" "outcome"="pass" "duration"=`)
+	require.Contains(t, lines, `"level"=2 "msg"="Validation rule evaluated" "rule"="upstream.unique" "outcome"="skip"`)
+}
+
+func TestRuleCheckWithoutLoggerDoesNotAllocate(t *testing.T) {
+	rules := ruleSet{}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		rules.check(RuleUpstreamPort, func() field.ErrorList {
+			return nil
+		})
+	})
+
+	require.Zero(t, allocs)
+}
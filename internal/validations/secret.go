@@ -0,0 +1,355 @@
+package validations
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// SecretGetter looks up the secret referenced by a config, found is false when the secret does not exist
+type SecretGetter interface {
+	Get(namespace, name string) (secret *v1.Secret, found bool, err error)
+}
+
+// caCertificateKey holds the PEM encoded CA bundle used to verify upstreams with a custom CA
+const caCertificateKey = "ca.crt"
+
+// acceptedSecretTypes are the secret types that can carry registry credentials, an empty type defaults to Opaque
+var acceptedSecretTypes = []v1.SecretType{v1.SecretTypeOpaque, v1.SecretTypeDockerConfigJson, v1.SecretTypeBasicAuth}
+
+const defaultSecretSizeWarningRatio = 0.8
+
+// caCertificateExpiryWarningPeriod is how long before its expiry a CA certificate triggers a warning
+const caCertificateExpiryWarningPeriod = 30 * 24 * time.Hour
+
+// validateSecretRequired demands a secret reference for upstreams the platform knows to serve private images only
+func (v Validator) validateSecretRequired(upstream string, fldPath *field.Path) field.ErrorList {
+	if len(v.options.UpstreamsRequiringAuth) == 0 {
+		return nil
+	}
+
+	return v.rules().check(RuleSecretRequired, func() field.ErrorList {
+		host := strings.ToLower(upstreamHost(upstream))
+
+		pattern, found, err := matchUpstreamPattern(host, v.options.UpstreamsRequiringAuth)
+		if err != nil {
+			return field.ErrorList{field.InternalError(fldPath, err)}
+		}
+
+		if found {
+			return field.ErrorList{field.Required(fldPath, fmt.Sprintf("upstream host %q matches the pattern %q of registries requiring authentication, reference a secret with the registry credentials", host, pattern))}
+		}
+
+		return nil
+	})
+}
+
+// ValidateSecretReference checks a single secret reference against the given secrets, the namespace is the one of the config
+// and the upstream is needed to find its credentials in a .dockerconfigjson key, warnings are dropped
+func ValidateSecretReference(namespace, secretName, upstream string, secrets []v1.Secret, fldPath *field.Path) field.ErrorList {
+	errs, _ := NewValidator(secrets, nil).validateSecretReference(context.Background(), namespace, secretName, upstream, fldPath)
+	return errs
+}
+
+func (v Validator) validateSecretReference(ctx context.Context, namespace, secretName, upstream string, fldPath *field.Path) (field.ErrorList, field.ErrorList) {
+	rules := v.rules()
+
+	// an invalid name can never be found, so the syntax error is reported instead of a misleading not found
+	if errs := rules.check(RuleSecretName, func() field.ErrorList {
+		if msgs := validation.IsDNS1123Subdomain(secretName); len(msgs) > 0 {
+			return field.ErrorList{field.Invalid(fldPath, secretName, strings.Join(msgs, "; "))}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs, nil
+	}
+
+	var secret *v1.Secret
+	var found bool
+
+	if v.secretGetter != nil {
+		if errs := interrupted(ctx, fldPath); len(errs) > 0 {
+			return errs, nil
+		}
+
+		var err error
+		if secret, found, err = v.secretGetter.Get(namespace, secretName); err != nil {
+			return field.ErrorList{field.InternalError(fldPath, fmt.Errorf("failed to get secret %q: %w", secretName, err))}, nil
+		}
+	}
+
+	if errs := rules.check(RuleSecretExists, func() field.ErrorList {
+		if !found {
+			return field.ErrorList{field.NotFound(fldPath, secretName)}
+		}
+
+		return nil
+	}); len(errs) > 0 || !found {
+		return errs, nil
+	}
+
+	errs := rules.check(RuleSecretImmutable, func() field.ErrorList {
+		if secret.Immutable == nil || !*secret.Immutable {
+			return field.ErrorList{field.Invalid(fldPath, secretName, "should be immutable")}
+		}
+
+		return nil
+	})
+
+	errs = append(errs, rules.check(RuleSecretType, func() field.ErrorList {
+		if secretType := secret.Type; secretType != "" && !slices.Contains(acceptedSecretTypes, secretType) {
+			return field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("secret has the type %q, the accepted types are %q", secretType, acceptedSecretTypes))}
+		}
+
+		return nil
+	})...)
+
+	casingErrs := rules.check(RuleSecretKeyCasing, func() field.ErrorList {
+		return validateSecretKeyCasing(secret, secretName, fldPath)
+	})
+	errs = append(errs, casingErrs...)
+
+	// the structure check would only repeat that the miscased keys are missing
+	if len(casingErrs) == 0 {
+		errs = append(errs, v.validateSecretStructure(secret, secretName, upstream, fldPath)...)
+	}
+
+	sizeErrs, warnings := rules.checkWithWarnings(RuleSecretSize, func() (field.ErrorList, field.ErrorList) {
+		return v.validateSecretSize(secret, secretName, fldPath)
+	})
+	errs = append(errs, sizeErrs...)
+
+	caCertificate, found := secret.Data[caCertificateKey]
+	if !found {
+		return errs, warnings
+	}
+
+	caErrs, caWarnings := rules.checkWithWarnings(RuleSecretCACertificate, func() (field.ErrorList, field.ErrorList) {
+		return validateCACertificate(caCertificate, secretName, fldPath, time.Now())
+	})
+
+	return append(errs, caErrs...), append(warnings, caWarnings...)
+}
+
+func (v Validator) validateSecretStructure(secret *v1.Secret, secretName, upstream string, fldPath *field.Path) field.ErrorList {
+	rules := v.rules()
+
+	errs := rules.check(RuleSecretStructure, func() field.ErrorList {
+		switch {
+		case hasDockerConfigJSONKey(secret) && hasAnyBasicAuthKey(secret):
+			return field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("secret contains both the %q key and the %q/%q keys so it is ambiguous which credentials are used, keep only one of the forms",
+				v1.DockerConfigJsonKey, v1.BasicAuthUsernameKey, v1.BasicAuthPasswordKey))}
+		case !hasDockerConfigJSON(secret) && !hasBasicAuthKeys(secret):
+			return field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("invalid secret reference: secret must contain either the %q key with type %q, or the %q and %q keys",
+				v1.DockerConfigJsonKey, v1.SecretTypeDockerConfigJson, v1.BasicAuthUsernameKey, v1.BasicAuthPasswordKey))}
+		}
+
+		return validateCredentialValues(secret, secretName, fldPath)
+	})
+
+	if len(errs) > 0 || !hasDockerConfigJSON(secret) {
+		return errs
+	}
+
+	return rules.check(RuleSecretDockerConfigJSON, func() field.ErrorList {
+		return validateDockerConfigJSON(secret.Data[v1.DockerConfigJsonKey], secretName, upstream, fldPath)
+	})
+}
+
+// validateCredentialValues flags credential keys without a value, e.g. a secret created from an unset environment variable
+func validateCredentialValues(secret *v1.Secret, secretName string, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	for _, key := range []string{v1.BasicAuthUsernameKey, v1.BasicAuthPasswordKey, v1.DockerConfigJsonKey} {
+		if value, found := secret.Data[key]; found && len(bytes.TrimSpace(value)) == 0 {
+			errs = append(errs, field.Invalid(fldPath, secretName, fmt.Sprintf("secret key %q is empty", key)))
+		}
+	}
+
+	return errs
+}
+
+// expectedSecretKeys are the keys read from the referenced secret, they are case-sensitive
+var expectedSecretKeys = []string{v1.BasicAuthUsernameKey, v1.BasicAuthPasswordKey, v1.DockerConfigJsonKey, caCertificateKey}
+
+func validateSecretKeyCasing(secret *v1.Secret, secretName string, fldPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	for _, key := range slices.Sorted(maps.Keys(secret.Data)) {
+		for _, expectedKey := range expectedSecretKeys {
+			if key != expectedKey && strings.EqualFold(key, expectedKey) {
+				errs = append(errs, field.Invalid(fldPath, secretName, fmt.Sprintf("secret key %q is ignored since keys are case-sensitive, rename it to %q", key, expectedKey)))
+			}
+		}
+	}
+
+	return errs
+}
+
+// validateSecretSize counts keys and values the same way the API server does when enforcing the secret size limit
+func (v Validator) validateSecretSize(secret *v1.Secret, secretName string, fldPath *field.Path) (field.ErrorList, field.ErrorList) {
+	var size int
+	for key, value := range secret.Data {
+		size += len(key) + len(value)
+	}
+
+	if size > v1.MaxSecretSize {
+		return field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("secret data is %d bytes which exceeds the limit of %d bytes", size, v1.MaxSecretSize))}, nil
+	}
+
+	ratio := v.options.SecretSizeWarningRatio
+	if ratio <= 0 {
+		ratio = defaultSecretSizeWarningRatio
+	}
+
+	if float64(size) > ratio*v1.MaxSecretSize {
+		return nil, field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("secret data is %d bytes which is close to the limit of %d bytes, later updates to the secret may fail", size, v1.MaxSecretSize))}
+	}
+
+	return nil, nil
+}
+
+func validateCACertificate(data []byte, secretName string, fldPath *field.Path, now time.Time) (field.ErrorList, field.ErrorList) {
+	var certificates []*x509.Certificate
+
+	rest := data
+	for {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			return field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("secret key %q contains a PEM block of type %q, only CERTIFICATE blocks are supported", caCertificateKey, block.Type))}, nil
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("secret key %q contains a certificate that cannot be parsed: %v", caCertificateKey, err))}, nil
+		}
+
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 || len(bytes.TrimSpace(rest)) > 0 {
+		return field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("secret key %q must only contain PEM encoded certificates", caCertificateKey))}, nil
+	}
+
+	var errs, warnings field.ErrorList
+
+	for _, certificate := range certificates {
+		notAfter := certificate.NotAfter.UTC().Format(time.RFC3339)
+
+		switch {
+		case now.After(certificate.NotAfter):
+			errs = append(errs, field.Invalid(fldPath, secretName, fmt.Sprintf("certificate %q in secret key %q expired on %s", certificate.Subject.CommonName, caCertificateKey, notAfter)))
+		case certificate.NotAfter.Sub(now) < caCertificateExpiryWarningPeriod:
+			warnings = append(warnings, field.Invalid(fldPath, secretName, fmt.Sprintf("certificate %q in secret key %q expires on %s, rotate it before it expires", certificate.Subject.CommonName, caCertificateKey, notAfter)))
+		}
+	}
+
+	return errs, warnings
+}
+
+type dockerConfigJSON struct {
+	Auths map[string]json.RawMessage `json:"auths"`
+}
+
+func validateDockerConfigJSON(data []byte, secretName, upstream string, fldPath *field.Path) field.ErrorList {
+	var config dockerConfigJSON
+	if err := json.Unmarshal(data, &config); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("secret contains malformed %q: %v", v1.DockerConfigJsonKey, err))}
+	}
+
+	normalized := canonicalRegistryHost(upstream)
+
+	for registry := range config.Auths {
+		if canonicalRegistryHost(dockerConfigRegistryHost(registry)) == normalized {
+			return nil
+		}
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, secretName, fmt.Sprintf("secret has no credentials for the upstream %q", upstream))}
+}
+
+// dockerHubHosts are the names Docker Hub is reached under, docker login stores its credentials as https://index.docker.io/v1/
+var dockerHubHosts = map[string]bool{"docker.io": true, "index.docker.io": true, "registry-1.docker.io": true}
+
+// canonicalRegistryHost normalizes the host and maps all Docker Hub names to docker.io
+func canonicalRegistryHost(host string) string {
+	normalized := normalizeUpstream(host)
+	if dockerHubHosts[normalized] {
+		return "docker.io"
+	}
+
+	return normalized
+}
+
+// dockerConfigRegistryHost strips the scheme and path that dockerconfigjson auth keys commonly carry, e.g. https://registry.example.com/
+func dockerConfigRegistryHost(registry string) string {
+	host, _ := stripUpstreamScheme(registry)
+	host, _, _ = strings.Cut(host, "/")
+
+	return host
+}
+
+// secretIndex looks secrets up without scanning the whole slice for every validated config
+type secretIndex map[types.NamespacedName]*v1.Secret
+
+func newSecretIndex(secrets []v1.Secret) secretIndex {
+	index := make(secretIndex, len(secrets))
+
+	for i := range secrets {
+		key := types.NamespacedName{Namespace: secrets[i].Namespace, Name: secrets[i].Name}
+
+		// the first secret wins, the same way a scan of the slice would resolve duplicates
+		if _, found := index[key]; !found {
+			index[key] = &secrets[i]
+		}
+	}
+
+	return index
+}
+
+func (s secretIndex) Get(namespace, name string) (*v1.Secret, bool, error) {
+	secret, found := s[types.NamespacedName{Namespace: namespace, Name: name}]
+
+	return secret, found, nil
+}
+
+func hasDockerConfigJSON(secret *v1.Secret) bool {
+	return hasDockerConfigJSONKey(secret) && secret.Type == v1.SecretTypeDockerConfigJson
+}
+
+func hasDockerConfigJSONKey(secret *v1.Secret) bool {
+	_, found := secret.Data[v1.DockerConfigJsonKey]
+
+	return found
+}
+
+func hasBasicAuthKeys(secret *v1.Secret) bool {
+	_, hasUsername := secret.Data[v1.BasicAuthUsernameKey]
+	_, hasPassword := secret.Data[v1.BasicAuthPasswordKey]
+
+	return hasUsername && hasPassword
+}
+
+func hasAnyBasicAuthKey(secret *v1.Secret) bool {
+	_, hasUsername := secret.Data[v1.BasicAuthUsernameKey]
+	_, hasPassword := secret.Data[v1.BasicAuthPasswordKey]
+
+	return hasUsername || hasPassword
+}
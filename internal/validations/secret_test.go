@@ -0,0 +1,359 @@
+package validations
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+type fakeSecretGetter struct {
+	secret *v1.Secret
+	err    error
+}
+
+func (f fakeSecretGetter) Get(namespace, name string) (*v1.Secret, bool, error) {
+	if f.err != nil {
+		return nil, false, f.err
+	}
+
+	if f.secret == nil || f.secret.Namespace != namespace || f.secret.Name != name {
+		return nil, false, nil
+	}
+
+	return f.secret, true, nil
+}
+
+func TestValidatorWithSecretGetter(t *testing.T) {
+	secretReferenceNameFieldPath := field.NewPath("spec").Child("secretReferenceName")
+
+	config := registrycache.RegistryCacheConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+		},
+		Spec: registrycache.RegistryCacheConfigSpec{
+			Upstream:            "docker.io",
+			SecretReferenceName: ptr.To("registry-credentials"),
+		},
+	}
+
+	for _, tt := range []struct {
+		name         string
+		secretGetter SecretGetter
+		errorsList   field.ErrorList
+	}{
+		{
+			name: "secret found",
+			secretGetter: fakeSecretGetter{
+				secret: &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "registry-credentials",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"username": []byte("dXNlcg=="),
+						"password": []byte("cGFzc3dvcmQ="),
+					},
+					Immutable: ptr.To(true),
+				},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "secret with capitalized keys",
+			secretGetter: fakeSecretGetter{
+				secret: &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "registry-credentials",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"Username": []byte("dXNlcg=="),
+						"PASSWORD": []byte("cGFzc3dvcmQ="),
+					},
+					Immutable: ptr.To(true),
+				},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", `secret key "PASSWORD" is ignored since keys are case-sensitive, rename it to "password"`),
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", `secret key "Username" is ignored since keys are case-sensitive, rename it to "username"`),
+			},
+		},
+		{
+			name: "secret with empty values",
+			secretGetter: fakeSecretGetter{
+				secret: &v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "registry-credentials",
+						Namespace: "default",
+					},
+					Data: map[string][]byte{
+						"username": []byte(""),
+						"password": []byte(" \n"),
+					},
+					Immutable: ptr.To(true),
+				},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", `secret key "username" is empty`),
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", `secret key "password" is empty`),
+			},
+		},
+		{
+			name:         "secret not found",
+			secretGetter: fakeSecretGetter{},
+			errorsList: field.ErrorList{
+				field.NotFound(secretReferenceNameFieldPath, "registry-credentials"),
+			},
+		},
+		{
+			name:         "secret getter failure",
+			secretGetter: fakeSecretGetter{err: errors.New("connection refused")},
+			errorsList: field.ErrorList{
+				field.InternalError(secretReferenceNameFieldPath, errors.New(`failed to get secret "registry-credentials": connection refused`)),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidatorWithSecretGetter(tt.secretGetter, nil, ValidationOptions{}).Do(&config)
+
+			requireErrorsMatch(t, tt.errorsList, errs)
+		})
+	}
+}
+
+func TestValidateSecretReference(t *testing.T) {
+	fldPath := field.NewPath("secretReferenceName")
+
+	secrets := []v1.Secret{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "registry-credentials",
+				Namespace: "default",
+			},
+			Data: map[string][]byte{
+				"username": []byte("dXNlcg=="),
+				"password": []byte("cGFzc3dvcmQ="),
+			},
+			Immutable: ptr.To(true),
+		},
+	}
+
+	for _, tt := range []struct {
+		name       string
+		namespace  string
+		secretName string
+		errorsList field.ErrorList
+	}{
+		{
+			name:       "secret found",
+			namespace:  "default",
+			secretName: "registry-credentials",
+			errorsList: field.ErrorList{},
+		},
+		{
+			name:       "secret in another namespace",
+			namespace:  "kube-system",
+			secretName: "registry-credentials",
+			errorsList: field.ErrorList{
+				field.NotFound(fldPath, "registry-credentials"),
+			},
+		},
+		{
+			name:       "invalid secret name",
+			namespace:  "default",
+			secretName: "Registry_Credentials",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "Registry_Credentials", "a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requireErrorsMatch(t, tt.errorsList, ValidateSecretReference(tt.namespace, tt.secretName, "docker.io", secrets, fldPath))
+		})
+	}
+}
+
+func TestValidateCACertificate(t *testing.T) {
+	secretReferenceNameFieldPath := field.NewPath("spec").Child("secretReferenceName")
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	validCertificate := generateCertificatePEM(t, "valid-ca", now.Add(365*24*time.Hour))
+	expiringCertificate := generateCertificatePEM(t, "expiring-ca", now.Add(10*24*time.Hour))
+	expiredCertificate := generateCertificatePEM(t, "expired-ca", now.Add(-time.Hour))
+
+	for _, tt := range []struct {
+		name         string
+		data         []byte
+		errorsList   field.ErrorList
+		warningsList field.ErrorList
+	}{
+		{
+			name:         "valid certificate",
+			data:         validCertificate,
+			errorsList:   field.ErrorList{},
+			warningsList: field.ErrorList{},
+		},
+		{
+			name:         "certificate bundle",
+			data:         append(append([]byte{}, validCertificate...), validCertificate...),
+			errorsList:   field.ErrorList{},
+			warningsList: field.ErrorList{},
+		},
+		{
+			name:       "certificate expiring soon",
+			data:       expiringCertificate,
+			errorsList: field.ErrorList{},
+			warningsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", `certificate "expiring-ca" in secret key "ca.crt" expires on 2025-01-11T00:00:00Z`),
+			},
+		},
+		{
+			name: "expired certificate",
+			data: expiredCertificate,
+			errorsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", `certificate "expired-ca" in secret key "ca.crt" expired on 2024-12-31T23:00:00Z`),
+			},
+			warningsList: field.ErrorList{},
+		},
+		{
+			name: "not PEM encoded",
+			data: []byte("not a certificate"),
+			errorsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", `secret key "ca.crt" must only contain PEM encoded certificates`),
+			},
+			warningsList: field.ErrorList{},
+		},
+		{
+			name: "trailing data after certificate",
+			data: append(append([]byte{}, validCertificate...), []byte("garbage")...),
+			errorsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", `secret key "ca.crt" must only contain PEM encoded certificates`),
+			},
+			warningsList: field.ErrorList{},
+		},
+		{
+			name: "PEM block of another type",
+			data: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}),
+			errorsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", `contains a PEM block of type "PRIVATE KEY"`),
+			},
+			warningsList: field.ErrorList{},
+		},
+		{
+			name: "unparseable certificate",
+			data: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("certificate")}),
+			errorsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", "contains a certificate that cannot be parsed"),
+			},
+			warningsList: field.ErrorList{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, warnings := validateCACertificate(tt.data, "registry-credentials", secretReferenceNameFieldPath, now)
+
+			requireErrorsMatch(t, tt.errorsList, errs)
+			requireErrorsMatch(t, tt.warningsList, warnings)
+		})
+	}
+}
+
+func generateCertificatePEM(t *testing.T, commonName string, notAfter time.Time) []byte {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             notAfter.Add(-2 * 365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestValidateSecretSize(t *testing.T) {
+	secretReferenceNameFieldPath := field.NewPath("spec").Child("secretReferenceName")
+
+	newSecret := func(size int) *v1.Secret {
+		return &v1.Secret{
+			Data: map[string][]byte{
+				"ca.crt": make([]byte, size-len("ca.crt")),
+			},
+		}
+	}
+
+	for _, tt := range []struct {
+		name         string
+		secret       *v1.Secret
+		options      ValidationOptions
+		errorsList   field.ErrorList
+		warningsList field.ErrorList
+	}{
+		{
+			name:         "small secret",
+			secret:       newSecret(1024),
+			errorsList:   field.ErrorList{},
+			warningsList: field.ErrorList{},
+		},
+		{
+			name:       "secret close to the limit",
+			secret:     newSecret(900 * 1024),
+			errorsList: field.ErrorList{},
+			warningsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", "secret data is 921600 bytes which is close to the limit of 1048576 bytes"),
+			},
+		},
+		{
+			name:   "secret above the limit",
+			secret: newSecret(v1.MaxSecretSize + 1),
+			errorsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", "secret data is 1048577 bytes which exceeds the limit of 1048576 bytes"),
+			},
+			warningsList: field.ErrorList{},
+		},
+		{
+			name:       "secret above a custom warning ratio",
+			secret:     newSecret(600 * 1024),
+			options:    ValidationOptions{SecretSizeWarningRatio: 0.5},
+			errorsList: field.ErrorList{},
+			warningsList: field.ErrorList{
+				field.Invalid(secretReferenceNameFieldPath, "registry-credentials", "close to the limit"),
+			},
+		},
+		{
+			name:         "secret below a custom warning ratio",
+			secret:       newSecret(900 * 1024),
+			options:      ValidationOptions{SecretSizeWarningRatio: 0.9},
+			errorsList:   field.ErrorList{},
+			warningsList: field.ErrorList{},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, warnings := NewValidatorWithOptions(nil, nil, tt.options).validateSecretSize(tt.secret, "registry-credentials", secretReferenceNameFieldPath)
+
+			requireErrorsMatch(t, tt.errorsList, errs)
+			requireErrorsMatch(t, tt.warningsList, warnings)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package validations
+
+import (
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+// validateEmptyOptionalFields reports optional fields that are set but empty, which usually points at a half-edited resource
+func validateEmptyOptionalFields(spec registrycache.RegistryCacheConfigSpec, specPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	requireNotEmpty := func(value *string, fldPath *field.Path) {
+		if value != nil && *value == "" {
+			errs = append(errs, field.Required(fldPath, "must not be empty when set, remove the field instead"))
+		}
+	}
+
+	requireNotEmpty(spec.RemoteURL, specPath.Child("remoteURL"))
+	requireNotEmpty(spec.SecretReferenceName, specPath.Child("secretReferenceName"))
+
+	if spec.Volume != nil {
+		if spec.Volume.Size == nil && spec.Volume.StorageClassName == nil {
+			errs = append(errs, field.Required(specPath.Child("volume"), "size or storageClassName must be set when volume is specified"))
+		}
+
+		requireNotEmpty(spec.Volume.StorageClassName, specPath.Child("volume").Child("storageClassName"))
+	}
+
+	// a proxy without any url is already rejected by validateProxy
+	if spec.Proxy != nil && (ptr.Deref(spec.Proxy.HTTPProxy, "") != "" || ptr.Deref(spec.Proxy.HTTPSProxy, "") != "") {
+		requireNotEmpty(spec.Proxy.HTTPProxy, specPath.Child("proxy").Child("httpProxy"))
+		requireNotEmpty(spec.Proxy.HTTPSProxy, specPath.Child("proxy").Child("httpsProxy"))
+	}
+
+	return errs
+}
+
+// replaceWithStrictErrors drops the errors reported for the empty fields since their syntax errors only obscure the real problem
+func replaceWithStrictErrors(errs, strictErrs field.ErrorList) field.ErrorList {
+	if len(strictErrs) == 0 {
+		return errs
+	}
+
+	emptyFields := make(map[string]bool, len(strictErrs))
+	for _, err := range strictErrs {
+		emptyFields[err.Field] = true
+	}
+
+	var kept field.ErrorList
+	for _, err := range errs {
+		if !emptyFields[err.Field] {
+			kept = append(kept, err)
+		}
+	}
+
+	kept = append(kept, strictErrs...)
+	sortErrors(kept)
+
+	return kept
+}
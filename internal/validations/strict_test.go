@@ -0,0 +1,101 @@
+package validations
+
+import (
+	"testing"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+func TestStrictMode(t *testing.T) {
+	specPath := field.NewPath("spec")
+
+	for _, tt := range []struct {
+		name       string
+		spec       registrycache.RegistryCacheConfigSpec
+		strict     bool
+		errorsList field.ErrorList
+	}{
+		{
+			name: "empty volume",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Volume:   &registrycache.Volume{},
+			},
+			strict: true,
+			errorsList: field.ErrorList{
+				field.Required(specPath.Child("volume"), "size or storageClassName must be set when volume is specified"),
+			},
+		},
+		{
+			name: "empty volume without strict mode",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Volume:   &registrycache.Volume{},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "empty storage class name replaces the syntax error",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Volume: &registrycache.Volume{
+					StorageClassName: ptr.To(""),
+				},
+			},
+			strict: true,
+			errorsList: field.ErrorList{
+				field.Required(specPath.Child("volume", "storageClassName"), "must not be empty when set"),
+			},
+		},
+		{
+			name: "empty storage class name without strict mode",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Volume: &registrycache.Volume{
+					StorageClassName: ptr.To(""),
+				},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(specPath.Child("volume", "storageClassName"), "", "a lowercase RFC 1123 subdomain"),
+			},
+		},
+		{
+			name: "empty proxy url next to a set one",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream: "docker.io",
+				Proxy: &registrycache.Proxy{
+					HTTPProxy:  ptr.To(""),
+					HTTPSProxy: ptr.To("https://proxy.corp:3128"),
+				},
+			},
+			strict: true,
+			errorsList: field.ErrorList{
+				field.Required(specPath.Child("proxy", "httpProxy"), "must not be empty when set"),
+			},
+		},
+		{
+			name: "empty remote url and secret reference name",
+			spec: registrycache.RegistryCacheConfigSpec{
+				Upstream:            "docker.io",
+				RemoteURL:           ptr.To(""),
+				SecretReferenceName: ptr.To(""),
+			},
+			strict: true,
+			errorsList: field.ErrorList{
+				field.Required(specPath.Child("remoteURL"), "must not be empty when set"),
+				field.Required(specPath.Child("secretReferenceName"), "must not be empty when set"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &registrycache.RegistryCacheConfig{ObjectMeta: metav1.ObjectMeta{Namespace: "default"}, Spec: tt.spec}
+
+			errs := NewValidatorWithOptions(nil, nil, ValidationOptions{Strict: tt.strict}).Do(config)
+
+			requireErrorsMatch(t, tt.errorsList, errs)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package validations
+
+import (
+	"fmt"
+	"slices"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+// DoOnUpdate only returns the errors introduced by the update, errors already present in the old config
+// with the same field and type are tolerated so that grandfathered configs can still be updated
+func (v Validator) DoOnUpdate(newConfig, oldConfig *registrycache.RegistryCacheConfig) field.ErrorList {
+	errs, _ := v.DoOnUpdateWithWarnings(newConfig, oldConfig)
+	return errs
+}
+
+// DoOnUpdateWithWarnings is DoOnUpdate that also returns the warnings of the new config, including the errors of warn-only
+// rules
+func (v Validator) DoOnUpdateWithWarnings(newConfig, oldConfig *registrycache.RegistryCacheConfig) (field.ErrorList, field.ErrorList) {
+	v.demoted = &demotedErrors{}
+	specPath := field.NewPath("spec")
+
+	errs := v.validateImmutableFields(newConfig.Spec, oldConfig.Spec, specPath)
+	errs = append(errs, v.rules().check(RuleVolumeSizeShrink, func() field.ErrorList {
+		return validateVolumeSizeUpdate(newConfig.Spec.Volume, oldConfig.Spec.Volume, specPath.Child("volume").Child("size"))
+	})...)
+
+	newErrs, warnings := v.DoWithWarnings(newConfig)
+	introduced, _, _ := diffErrors(newErrs, v.Do(oldConfig))
+	errs = append(errs, introduced...)
+	warnings = append(warnings, v.demoted.errs...)
+
+	sortErrors(errs)
+	sortErrors(warnings)
+
+	return errs, warnings
+}
+
+// DoDiff partitions the validation errors of two configs into those only a has, those only b has and those both have,
+// errors are matched by field and type and the common ones are returned as reported for a
+func (v Validator) DoDiff(a, b *registrycache.RegistryCacheConfig) (field.ErrorList, field.ErrorList, field.ErrorList) {
+	return diffErrors(v.Do(a), v.Do(b))
+}
+
+func diffErrors(aErrs, bErrs field.ErrorList) (field.ErrorList, field.ErrorList, field.ErrorList) {
+	aKeys, bKeys := errorKeys(aErrs), errorKeys(bErrs)
+
+	var onlyA, onlyB, common field.ErrorList
+
+	for _, err := range aErrs {
+		if bKeys[keyOf(err)] {
+			common = append(common, err)
+		} else {
+			onlyA = append(onlyA, err)
+		}
+	}
+
+	for _, err := range bErrs {
+		if !aKeys[keyOf(err)] {
+			onlyB = append(onlyB, err)
+		}
+	}
+
+	return onlyA, onlyB, common
+}
+
+type errorKey struct {
+	field   string
+	errType field.ErrorType
+}
+
+func keyOf(err *field.Error) errorKey {
+	return errorKey{field: err.Field, errType: err.Type}
+}
+
+func errorKeys(errs field.ErrorList) map[errorKey]bool {
+	keys := make(map[errorKey]bool, len(errs))
+	for _, err := range errs {
+		keys[keyOf(err)] = true
+	}
+
+	return keys
+}
+
+func (v Validator) validateImmutableFields(newSpec, oldSpec registrycache.RegistryCacheConfigSpec, specPath *field.Path) field.ErrorList {
+	var errs field.ErrorList
+
+	if upstreamPath := specPath.Child("upstream"); v.isImmutable(upstreamPath) {
+		errs = append(errs, v.rules().check(RuleUpstreamImmutable, func() field.ErrorList {
+			return apivalidation.ValidateImmutableField(newSpec.Upstream, oldSpec.Upstream, upstreamPath)
+		})...)
+	}
+
+	// setting the storage class for the first time is allowed, changing or clearing it would require a new volume
+	storageClassNamePath := specPath.Child("volume").Child("storageClassName")
+	if oldName := storageClassName(oldSpec); oldName != nil && v.isImmutable(storageClassNamePath) {
+		errs = append(errs, v.rules().check(RuleStorageClassNameImmutable, func() field.ErrorList {
+			return apivalidation.ValidateImmutableField(ptr.Deref(storageClassName(newSpec), ""), *oldName, storageClassNamePath)
+		})...)
+	}
+
+	return errs
+}
+
+func (v Validator) isImmutable(fldPath *field.Path) bool {
+	return !slices.Contains(v.options.MutableFields, fldPath.String())
+}
+
+func storageClassName(spec registrycache.RegistryCacheConfigSpec) *string {
+	if spec.Volume == nil {
+		return nil
+	}
+
+	return spec.Volume.StorageClassName
+}
+
+func validateVolumeSizeUpdate(newVolume, oldVolume *registrycache.Volume, fldPath *field.Path) field.ErrorList {
+	if newVolume == nil || newVolume.Size == nil || oldVolume == nil || oldVolume.Size == nil {
+		return nil
+	}
+
+	if newVolume.Size.Cmp(*oldVolume.Size) >= 0 {
+		return nil
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, newVolume.Size.String(), fmt.Sprintf("size cannot be decreased from %s to %s, persistent volume claims can only grow", oldVolume.Size.String(), newVolume.Size.String()))}
+}
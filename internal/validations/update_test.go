@@ -0,0 +1,390 @@
+package validations
+
+import (
+	"testing"
+	"time"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+func TestDoOnUpdate(t *testing.T) {
+	upstreamFieldPath := field.NewPath("spec").Child("upstream")
+	volumeSizeFieldPath := field.NewPath("spec").Child("volume").Child("size")
+	storageClassNameFieldPath := field.NewPath("spec").Child("volume").Child("storageClassName")
+
+	for _, tt := range []struct {
+		name       string
+		oldConfig  registrycache.RegistryCacheConfig
+		newConfig  registrycache.RegistryCacheConfig
+		options    ValidationOptions
+		errorsList field.ErrorList
+	}{
+		{
+			name: "valid update",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("10Gi")),
+					},
+				},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "pre-existing error is tolerated",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream:  "docker.io",
+					RemoteURL: ptr.To(InvalidRemoteURL),
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream:  "docker.io",
+					RemoteURL: ptr.To("ftp://other-mirror.example.com"),
+				},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "new error is reported",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream:  "docker.io",
+					RemoteURL: ptr.To(InvalidRemoteURL),
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream:  "docker.io",
+					RemoteURL: ptr.To(InvalidRemoteURL),
+					GarbageCollection: &registrycache.GarbageCollection{
+						TTL: metav1.Duration{Duration: -time.Second},
+					},
+				},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(field.NewPath("spec").Child("garbageCollection").Child("ttl"), -time.Second, "ttl must be a non-negative duration"),
+			},
+		},
+		{
+			name: "error fixed by the update",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream:  "docker.io",
+					RemoteURL: ptr.To(InvalidRemoteURL),
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream:  "docker.io",
+					RemoteURL: ptr.To("https://registry-1.docker.io"),
+				},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "upstream changed",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "quay.io",
+				},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(upstreamFieldPath, "quay.io", "field is immutable"),
+			},
+		},
+		{
+			name: "upstream changed when it is configured as mutable",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "quay.io",
+				},
+			},
+			options: ValidationOptions{
+				MutableFields: []string{"spec.upstream"},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "storage class name set for the first time",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						StorageClassName: ptr.To("premium"),
+					},
+				},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "storage class name changed",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						StorageClassName: ptr.To("standard"),
+					},
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						StorageClassName: ptr.To("premium"),
+					},
+				},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(storageClassNameFieldPath, "premium", "field is immutable"),
+			},
+		},
+		{
+			name: "storage class name cleared",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						StorageClassName: ptr.To("standard"),
+					},
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+				},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(storageClassNameFieldPath, "", "field is immutable"),
+			},
+		},
+		{
+			name: "storage class name unchanged",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						StorageClassName: ptr.To("standard"),
+					},
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size:             ptr.To(resource.MustParse("10Gi")),
+						StorageClassName: ptr.To("standard"),
+					},
+				},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "volume size decreased",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("20Gi")),
+					},
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("10Gi")),
+					},
+				},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(volumeSizeFieldPath, "10Gi", "size cannot be decreased from 20Gi to 10Gi"),
+			},
+		},
+		{
+			name: "volume size decreased across units",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("1Gi")),
+					},
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("1000Mi")),
+					},
+				},
+			},
+			errorsList: field.ErrorList{
+				field.Invalid(volumeSizeFieldPath, "1000Mi", "persistent volume claims can only grow"),
+			},
+		},
+		{
+			name: "volume size increased",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("10Gi")),
+					},
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("20Gi")),
+					},
+				},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "volume size unchanged in another unit",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("1Gi")),
+					},
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: "docker.io",
+					Volume: &registrycache.Volume{
+						Size: ptr.To(resource.MustParse("1024Mi")),
+					},
+				},
+			},
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "same field with a different error type",
+			oldConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					Upstream: InvalidUpstreamPort,
+				},
+			},
+			newConfig: registrycache.RegistryCacheConfig{
+				Spec: registrycache.RegistryCacheConfigSpec{
+					RemoteURL: ptr.To("https://registry-1.docker.io"),
+				},
+			},
+			options: ValidationOptions{
+				MutableFields: []string{"spec.upstream"},
+			},
+			errorsList: field.ErrorList{
+				field.Required(upstreamFieldPath, "upstream must be provided"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := NewValidatorWithOptions(nil, nil, tt.options).DoOnUpdate(&tt.newConfig, &tt.oldConfig)
+
+			requireErrorsMatch(t, tt.errorsList, errs)
+		})
+	}
+}
+
+func TestDoOnUpdateWithWarnings(t *testing.T) {
+	upstreamFieldPath := field.NewPath("spec").Child("upstream")
+
+	oldConfig := registrycache.RegistryCacheConfig{
+		Spec: registrycache.RegistryCacheConfigSpec{
+			Upstream: "docker.io",
+			Volume: &registrycache.Volume{
+				Size: ptr.To(resource.MustParse("10Gi")),
+			},
+		},
+	}
+	newConfig := registrycache.RegistryCacheConfig{
+		Spec: registrycache.RegistryCacheConfigSpec{
+			Upstream: "quay.io",
+			Volume: &registrycache.Volume{
+				Size: ptr.To(resource.MustParse("5Gi")),
+			},
+		},
+	}
+
+	validator := NewValidatorWithOptions(nil, nil, ValidationOptions{
+		WarnOnlyRules: map[string]bool{RuleUpstreamImmutable: true},
+	})
+
+	errs, warnings := validator.DoOnUpdateWithWarnings(&newConfig, &oldConfig)
+
+	requireErrorsMatch(t, field.ErrorList{
+		field.Invalid(field.NewPath("spec").Child("volume").Child("size"), "5Gi", "size cannot be decreased from 10Gi to 5Gi"),
+	}, errs)
+	requireErrorsMatch(t, field.ErrorList{
+		field.Invalid(upstreamFieldPath, "quay.io", "field is immutable"),
+	}, warnings)
+	requireErrorsMatch(t, errs, validator.DoOnUpdate(&newConfig, &oldConfig))
+}
+
+func TestDoDiff(t *testing.T) {
+	specPath := field.NewPath("spec")
+
+	a := &registrycache.RegistryCacheConfig{
+		Spec: registrycache.RegistryCacheConfigSpec{
+			Upstream:  "docker.io:77777",
+			RemoteURL: ptr.To("docker.io"),
+			GarbageCollection: &registrycache.GarbageCollection{
+				TTL: metav1.Duration{Duration: -time.Hour},
+			},
+		},
+	}
+
+	b := &registrycache.RegistryCacheConfig{
+		Spec: registrycache.RegistryCacheConfigSpec{
+			Upstream:  "docker.io:0",
+			RemoteURL: ptr.To("https://registry-1.docker.io"),
+			Volume: &registrycache.Volume{
+				Size: ptr.To(resource.MustParse("-1Gi")),
+			},
+		},
+	}
+
+	onlyA, onlyB, common := NewValidator(nil, nil).DoDiff(a, b)
+
+	requireErrorsMatch(t, field.ErrorList{
+		field.Invalid(specPath.Child("garbageCollection", "ttl"), -time.Hour, "ttl must be a non-negative duration"),
+		field.Invalid(specPath.Child("remoteURL"), "docker.io", "url must start with"),
+	}, onlyA)
+	requireErrorsMatch(t, field.ErrorList{
+		field.Invalid(specPath.Child("volume", "size"), "-1Gi", "must be greater than 0"),
+	}, onlyB)
+	requireErrorsMatch(t, field.ErrorList{
+		field.Invalid(specPath.Child("upstream"), "docker.io:77777", "valid port must be in the range [1, 65535]"),
+	}, common)
+}
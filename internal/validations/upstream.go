@@ -0,0 +1,424 @@
+package validations
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// upstreamDefaultPort is the port the registry cache connects to when the upstream does not set one
+const upstreamDefaultPort = 443
+
+var upstreamSchemes = []string{"http://", "https://", "tcp://"}
+
+// wellKnownRegistryPorts maps public registries to the port they serve https on
+var wellKnownRegistryPorts = map[string]int{"docker.io": 443, "ghcr.io": 443, "quay.io": 443, "gcr.io": 443}
+
+// privateUpstreamRanges are rejected by ValidationOptions.ForbidPrivateUpstream, they cover RFC 1918, unique local,
+// link-local and loopback addresses
+var privateUpstreamRanges = []netip.Prefix{
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("169.254.0.0/16"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("fc00::/7"),
+	netip.MustParsePrefix("fe80::/10"),
+	netip.MustParsePrefix("::1/128"),
+}
+
+// ValidateUpstream checks that the upstream is a bare host or host:port with a valid port
+func ValidateUpstream(upstream string, fldPath *field.Path) field.ErrorList {
+	return validateUpstream(upstream, fldPath, ruleSet{})
+}
+
+func validateUpstream(upstream string, fldPath *field.Path, rules ruleSet) field.ErrorList {
+	if errs := rules.check(RuleUpstreamRequired, func() field.ErrorList {
+		if upstream == "" {
+			return field.ErrorList{field.Required(fldPath, "upstream must be provided")}
+		}
+
+		return nil
+	}); len(errs) > 0 || upstream == "" {
+		return errs
+	}
+
+	if errs := rules.check(RuleUpstreamUnixSocket, func() field.ErrorList {
+		if strings.HasPrefix(strings.ToLower(upstream), "unix:") || strings.HasPrefix(upstream, "/") {
+			return field.ErrorList{field.Invalid(fldPath, upstream, "unix socket upstreams are not supported, only TCP host[:port] upstreams are supported")}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	if errs := rules.check(RuleUpstreamScheme, func() field.ErrorList {
+		if stripped, found := stripUpstreamScheme(upstream); found {
+			return field.ErrorList{field.Invalid(fldPath, upstream, fmt.Sprintf("upstream must be a bare host or host:port without a scheme, use %q instead", stripped))}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	if errs := rules.check(RuleUpstreamQuery, func() field.ErrorList {
+		if i := strings.IndexAny(upstream, "?#"); i >= 0 {
+			hostPort, _, _ := strings.Cut(upstream[:i], "/")
+			return field.ErrorList{field.Invalid(fldPath, upstream, fmt.Sprintf("upstream must only contain a host and an optional port without a query string or fragment, use %q instead", hostPort))}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	if errs := rules.check(RuleUpstreamPath, func() field.ErrorList {
+		if hostPort, _, found := strings.Cut(upstream, "/"); found {
+			return field.ErrorList{field.Invalid(fldPath, upstream, fmt.Sprintf("upstream must only contain a host and an optional port without a path or trailing slash, use %q instead", hostPort))}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	return validateUpstreamHostPort(upstream, fldPath, rules)
+}
+
+// parseUpstream splits a valid upstream into its host and port, the port is 0 when the upstream does not set one
+func parseUpstream(upstream string) (string, int, error) {
+	if errs := validateUpstream(upstream, field.NewPath("upstream"), ruleSet{}); len(errs) > 0 {
+		return "", 0, errs.ToAggregate()
+	}
+
+	host := upstreamHost(upstream)
+	if !hasUpstreamPort(upstream) {
+		return host, 0, nil
+	}
+
+	port, err := strconv.Atoi(upstream[strings.LastIndex(upstream, ":")+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid upstream port: %w", err)
+	}
+
+	return host, port, nil
+}
+
+// EffectiveUpstream returns the lowercased host and the port the registry cache connects to, e.g. docker.io and 443 for docker.io,
+// spec.remoteURL takes precedence over the upstream when it is set, invalid values are reported as an error instead of guessing
+func EffectiveUpstream(config *registrycache.RegistryCacheConfig) (string, int, error) {
+	host, port, err := parseUpstream(config.Spec.Upstream)
+	if err != nil {
+		return "", 0, err
+	}
+
+	if config.Spec.RemoteURL != nil {
+		return effectiveRemoteURL(*config.Spec.RemoteURL)
+	}
+
+	if port == 0 {
+		port = upstreamDefaultPort
+	}
+
+	return strings.ToLower(host), port, nil
+}
+
+// effectiveRemoteURL derives the port from the scheme of the remote url unless it sets one explicitly
+func effectiveRemoteURL(remoteURL string) (string, int, error) {
+	if errs := validateURL(remoteURL, field.NewPath("spec", "remoteURL")); len(errs) > 0 {
+		return "", 0, errs.ToAggregate()
+	}
+
+	parsed, err := url.Parse(remoteURL)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid remote url: %w", err)
+	}
+
+	if parsed.Hostname() == "" {
+		return "", 0, fmt.Errorf("invalid remote url %q: missing host", remoteURL)
+	}
+
+	portStr := parsed.Port()
+	if portStr == "" {
+		portStr = defaultPorts[parsed.Scheme]
+	}
+
+	if err := validatePort(parsed.Hostname(), portStr); err != nil {
+		return "", 0, fmt.Errorf("invalid remote url port: %w", err)
+	}
+
+	port, _ := strconv.Atoi(portStr)
+
+	return strings.ToLower(parsed.Hostname()), port, nil
+}
+
+func stripUpstreamScheme(upstream string) (string, bool) {
+	lowered := strings.ToLower(upstream)
+
+	for _, scheme := range upstreamSchemes {
+		if strings.HasPrefix(lowered, scheme) {
+			return upstream[len(scheme):], true
+		}
+	}
+
+	return upstream, false
+}
+
+func validateUpstreamHostPort(upstream string, fldPath *field.Path, rules ruleSet) field.ErrorList {
+	if strings.HasPrefix(upstream, "[") {
+		return validateBracketedIPv6Upstream(upstream, fldPath, rules)
+	}
+
+	// more than one colon without brackets can only be a bare IPv6 address without a port
+	if strings.Count(upstream, ":") > 1 {
+		return rules.check(RuleUpstreamHost, func() field.ErrorList {
+			if addr, err := netip.ParseAddr(upstream); err != nil || !addr.Is6() || addr.Zone() != "" {
+				return field.ErrorList{field.Invalid(fldPath, upstream, "must be a valid IPv6 address without a zone, enclose it in brackets to specify a port")}
+			}
+
+			return nil
+		})
+	}
+
+	host, port, hasPort := strings.Cut(upstream, ":")
+
+	errs := rules.check(RuleUpstreamHost, func() field.ErrorList {
+		return validateHost(host, upstream, fldPath)
+	})
+
+	if hasPort {
+		errs = append(errs, rules.check(RuleUpstreamPort, func() field.ErrorList {
+			return validatePortField(upstream, host, port, fldPath)
+		})...)
+	}
+
+	return errs
+}
+
+func validateBracketedIPv6Upstream(upstream string, fldPath *field.Path, rules ruleSet) field.ErrorList {
+	end := strings.Index(upstream, "]")
+
+	if errs := rules.check(RuleUpstreamHost, func() field.ErrorList {
+		if end < 0 {
+			return field.ErrorList{field.Invalid(fldPath, upstream, "missing closing bracket of the IPv6 address")}
+		}
+
+		if addr, err := netip.ParseAddr(upstream[1:end]); err != nil || !addr.Is6() || addr.Zone() != "" {
+			return field.ErrorList{field.Invalid(fldPath, upstream, "must be a valid IPv6 address without a zone inside brackets")}
+		}
+
+		return nil
+	}); len(errs) > 0 || end < 0 {
+		return errs
+	}
+
+	rest := upstream[end+1:]
+	if rest == "" {
+		return nil
+	}
+
+	return rules.check(RuleUpstreamPort, func() field.ErrorList {
+		if !strings.HasPrefix(rest, ":") {
+			return field.ErrorList{field.Invalid(fldPath, upstream, "only a port may follow the bracketed IPv6 address")}
+		}
+
+		return validatePortField(upstream, upstream[1:end], rest[1:], fldPath)
+	})
+}
+
+// normalizeUpstream returns a comparable form of the upstream, with a lowercased host and without the default https port
+func normalizeUpstream(upstream string) string {
+	normalized := strings.ToLower(upstream)
+
+	if strings.HasPrefix(normalized, "[") || strings.Count(normalized, ":") == 1 {
+		normalized = strings.TrimSuffix(normalized, ":443")
+	}
+
+	return normalized
+}
+
+func (v Validator) warnOnUpstream(upstream string, fldPath *field.Path) field.ErrorList {
+	if _, wellKnown := v.wellKnownRegistryPorts()[strings.ToLower(upstream)]; wellKnown || hasUpstreamPort(upstream) {
+		return nil
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, upstream, "upstream has no port so 443 is assumed, make sure the registry listens on it or set the port explicitly")}
+}
+
+// wellKnownRegistryPorts returns the table from the options, falling back to the built-in one
+func (v Validator) wellKnownRegistryPorts() map[string]int {
+	if v.options.WellKnownRegistryPorts != nil {
+		return v.options.WellKnownRegistryPorts
+	}
+
+	return wellKnownRegistryPorts
+}
+
+// warnOnWellKnownRegistryPort flags a well-known registry paired with the plaintext port, e.g. docker.io:80, other ports
+// are left alone as they may be a deliberate choice
+func (v Validator) warnOnWellKnownRegistryPort(upstream string, fldPath *field.Path) field.ErrorList {
+	host, port, err := parseUpstream(upstream)
+	if err != nil || port != 80 {
+		return nil
+	}
+
+	expectedPort, found := v.wellKnownRegistryPorts()[strings.ToLower(host)]
+	if !found || port == expectedPort {
+		return nil
+	}
+
+	detail := fmt.Sprintf("%s serves on port %d, port %d is almost certainly wrong, use %d", host, expectedPort, port, expectedPort)
+	if expectedPort == 443 {
+		detail += " or remove the port"
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, upstream, detail)}
+}
+
+// warnOnIPLiteralUpstream flags IP address hosts, TLS clients do not send them as SNI so registries serving several
+// hostnames behind one address often reject the connection
+func warnOnIPLiteralUpstream(upstream string, fldPath *field.Path) field.ErrorList {
+	host, _, err := parseUpstream(upstream)
+	if err != nil {
+		return nil
+	}
+
+	if _, err := netip.ParseAddr(host); err != nil {
+		return nil
+	}
+
+	return field.ErrorList{field.Invalid(fldPath, upstream, fmt.Sprintf("upstream host %s is an IP address which cannot be sent as TLS SNI, many registries reject such connections, use a hostname instead", host))}
+}
+
+func (v Validator) warnOnSelfReferencingUpstream(upstream string, fldPath *field.Path) field.ErrorList {
+	if len(v.options.SelfHosts) == 0 {
+		return nil
+	}
+
+	return v.rules().check(RuleUpstreamSelfLoop, func() field.ErrorList {
+		normalized := normalizeUpstream(upstream)
+
+		for _, selfHost := range v.options.SelfHosts {
+			if normalizeUpstream(selfHost) == normalized {
+				return field.ErrorList{field.Invalid(fldPath, upstream, fmt.Sprintf("upstream points to the registry cache itself (%s) which creates a loop", selfHost))}
+			}
+		}
+
+		return nil
+	})
+}
+
+func hasUpstreamPort(upstream string) bool {
+	if strings.HasPrefix(upstream, "[") {
+		return strings.Contains(upstream, "]:")
+	}
+
+	return strings.Count(upstream, ":") == 1
+}
+
+// validateUpstreamPolicy matches the upstream host, without its port, against the glob patterns of the allow and deny lists
+func (v Validator) validateUpstreamPolicy(upstream string, fldPath *field.Path) field.ErrorList {
+	if len(v.options.AllowedUpstreams) == 0 && len(v.options.DeniedUpstreams) == 0 {
+		return nil
+	}
+
+	return v.rules().check(RuleUpstreamPolicy, func() field.ErrorList {
+		return v.checkUpstreamPolicy(upstream, fldPath)
+	})
+}
+
+// validateUpstreamNotPrivate only inspects IP literals, hostnames are not resolved to keep DNS out of the validation
+func (v Validator) validateUpstreamNotPrivate(upstream string, fldPath *field.Path) field.ErrorList {
+	if !v.options.ForbidPrivateUpstream {
+		return nil
+	}
+
+	return v.rules().check(RuleUpstreamPrivate, func() field.ErrorList {
+		host, _, err := parseUpstream(upstream)
+		if err != nil {
+			return nil
+		}
+
+		addr, err := netip.ParseAddr(host)
+		if err != nil {
+			return nil
+		}
+
+		for _, prefix := range privateUpstreamRanges {
+			if prefix.Contains(addr.Unmap()) {
+				return field.ErrorList{field.Forbidden(fldPath, fmt.Sprintf("upstream host %s is inside the private range %s, only public registries can be cached", host, prefix))}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (v Validator) checkUpstreamPolicy(upstream string, fldPath *field.Path) field.ErrorList {
+	host := strings.ToLower(upstreamHost(upstream))
+
+	pattern, found, err := matchUpstreamPattern(host, v.options.DeniedUpstreams)
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, err)}
+	}
+
+	if found {
+		return field.ErrorList{field.Forbidden(fldPath, fmt.Sprintf("upstream host %q matches the denied pattern %q", host, pattern))}
+	}
+
+	if len(v.options.AllowedUpstreams) == 0 {
+		return nil
+	}
+
+	_, found, err = matchUpstreamPattern(host, v.options.AllowedUpstreams)
+	if err != nil {
+		return field.ErrorList{field.InternalError(fldPath, err)}
+	}
+
+	if !found {
+		return field.ErrorList{field.Forbidden(fldPath, fmt.Sprintf("upstream host %q does not match any of the allowed patterns %q", host, v.options.AllowedUpstreams))}
+	}
+
+	return nil
+}
+
+// matchUpstreamPattern checks every pattern before matching any, a malformed pattern never matches so skipping it would
+// let every upstream through a denied pattern
+func matchUpstreamPattern(host string, patterns []string) (string, bool, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return "", false, fmt.Errorf("invalid upstream pattern %q: %w", pattern, err)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(strings.ToLower(pattern), host); matched {
+			return pattern, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+func upstreamHost(upstream string) string {
+	if strings.HasPrefix(upstream, "[") {
+		host, _, _ := strings.Cut(upstream[1:], "]")
+		return host
+	}
+
+	if strings.Count(upstream, ":") > 1 {
+		return upstream
+	}
+
+	host, _, _ := strings.Cut(upstream, ":")
+
+	return host
+}
@@ -0,0 +1,371 @@
+package validations
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/utils/ptr"
+)
+
+func TestValidateUpstream(t *testing.T) {
+	fldPath := field.NewPath("upstream")
+
+	label63 := strings.Repeat("a", 63)
+	label64 := strings.Repeat("a", 64)
+	host253 := strings.Join([]string{label63, label63, label63, strings.Repeat("b", 61)}, ".")
+	host254 := strings.Join([]string{label63, label63, label63, strings.Repeat("b", 62)}, ".")
+
+	for _, tt := range []struct {
+		name       string
+		upstream   string
+		errorsList field.ErrorList
+	}{
+		{
+			name:       "host only",
+			upstream:   "docker.io",
+			errorsList: field.ErrorList{},
+		},
+		{
+			name:       "host with port",
+			upstream:   "my-registry.internal:5000",
+			errorsList: field.ErrorList{},
+		},
+		{
+			name:       "IPv4 host with port",
+			upstream:   "10.0.0.1:5000",
+			errorsList: field.ErrorList{},
+		},
+		{
+			name:     "out of range IPv4 host",
+			upstream: "999.999.999.999",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "999.999.999.999", "host 999.999.999.999 is not a valid IPv4 address"),
+			},
+		},
+		{
+			name:     "truncated IPv4 host with port",
+			upstream: "10.0.1:5000",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "10.0.1:5000", "host 10.0.1 is not a valid IPv4 address"),
+			},
+		},
+		{
+			name:     "port without host",
+			upstream: ":443",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, ":443", "a lowercase RFC 1123 subdomain must consist of"),
+				field.Invalid(fldPath, ":443", "port 443 is not preceded by a host"),
+			},
+		},
+		{
+			name:       "uppercase host",
+			upstream:   "Registry.Example.com",
+			errorsList: field.ErrorList{},
+		},
+		{
+			name:     "host with underscore",
+			upstream: "my_registry.io",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "my_registry.io", "a lowercase RFC 1123 subdomain must consist of"),
+			},
+		},
+		{
+			name:     "host with leading dot",
+			upstream: ".registry.io:5000",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, ".registry.io:5000", "a lowercase RFC 1123 subdomain must consist of"),
+			},
+		},
+		{
+			name:     "host with double dots and invalid port",
+			upstream: "registry..io:0",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "registry..io:0", "a lowercase RFC 1123 subdomain must consist of"),
+				field.Invalid(fldPath, "registry..io:0", "valid port must be in the range [1, 65535]"),
+			},
+		},
+		{
+			name:     "empty upstream",
+			upstream: "",
+			errorsList: field.ErrorList{
+				field.Required(fldPath, "upstream must be provided"),
+			},
+		},
+		{
+			name:     "upstream with scheme",
+			upstream: "https://docker.io",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "https://docker.io", `use "docker.io" instead`),
+			},
+		},
+		{
+			name:     "upstream with path",
+			upstream: "docker.io/library",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "docker.io/library", `use "docker.io" instead`),
+			},
+		},
+		{
+			name:     "upstream with trailing slash",
+			upstream: "docker.io:5000/",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "docker.io:5000/", `use "docker.io:5000" instead`),
+			},
+		},
+		{
+			name:     "unix socket upstream",
+			upstream: "unix:///var/run/registry.sock",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "unix:///var/run/registry.sock", "only TCP host[:port] upstreams are supported"),
+			},
+		},
+		{
+			name:     "socket path upstream",
+			upstream: "/var/run/registry.sock",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "/var/run/registry.sock", "unix socket upstreams are not supported"),
+			},
+		},
+		{
+			name:     "upstream with query string",
+			upstream: "docker.io?foo=bar",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "docker.io?foo=bar", `without a query string or fragment, use "docker.io" instead`),
+			},
+		},
+		{
+			name:     "upstream with path and fragment",
+			upstream: "docker.io:5000/library#tag",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "docker.io:5000/library#tag", `without a query string or fragment, use "docker.io:5000" instead`),
+			},
+		},
+		{
+			name:       "upstream with port and no path",
+			upstream:   "docker.io:5000",
+			errorsList: field.ErrorList{},
+		},
+		{
+			name:     "port out of range",
+			upstream: "docker.io:77777",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "docker.io:77777", "valid port must be in the range [1, 65535]"),
+			},
+		},
+		{
+			name:       "label with 63 characters",
+			upstream:   label63 + ".example.com:5000",
+			errorsList: field.ErrorList{},
+		},
+		{
+			name:     "label with 64 characters",
+			upstream: label64 + ".example.com:5000",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, label64+".example.com:5000", "is 64 characters long which exceeds the DNS limit of 63 characters per label"),
+			},
+		},
+		{
+			name:       "host with 253 characters",
+			upstream:   host253 + ":5000",
+			errorsList: field.ErrorList{},
+		},
+		{
+			name:     "host with 254 characters",
+			upstream: host254 + ":5000",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, host254+":5000", "host is 254 characters long which exceeds the DNS limit of 253 characters"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requireErrorsMatch(t, tt.errorsList, ValidateUpstream(tt.upstream, fldPath))
+		})
+	}
+}
+
+func TestParseUpstream(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		upstream string
+		host     string
+		port     int
+		wantErr  bool
+	}{
+		{
+			name:     "host without port",
+			upstream: "docker.io",
+			host:     "docker.io",
+		},
+		{
+			name:     "host with port",
+			upstream: "my-registry.internal:5000",
+			host:     "my-registry.internal",
+			port:     5000,
+		},
+		{
+			name:     "bare IPv6 address",
+			upstream: "fd00::1",
+			host:     "fd00::1",
+		},
+		{
+			name:     "bracketed IPv6 address with port",
+			upstream: "[fd00::1]:5000",
+			host:     "fd00::1",
+			port:     5000,
+		},
+		{
+			name:     "scheme",
+			upstream: "https://docker.io",
+			wantErr:  true,
+		},
+		{
+			name:     "empty port",
+			upstream: "docker.io:",
+			wantErr:  true,
+		},
+		{
+			name:     "IPv6 address with zone",
+			upstream: "[fe80::1%eth0]:5000",
+			wantErr:  true,
+		},
+		{
+			name:     "unclosed bracket",
+			upstream: "[fd00::1:5000",
+			wantErr:  true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, err := parseUpstream(tt.upstream)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.host, host)
+			require.Equal(t, tt.port, port)
+		})
+	}
+}
+
+func TestEffectiveUpstream(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		upstream  string
+		remoteURL *string
+		host      string
+		port      int
+		wantErr   bool
+	}{
+		{
+			name:     "host without port",
+			upstream: "docker.io",
+			host:     "docker.io",
+			port:     443,
+		},
+		{
+			name:      "http remote url",
+			upstream:  "registry.example.com",
+			remoteURL: ptr.To("http://Mirror.Example.com"),
+			host:      "mirror.example.com",
+			port:      80,
+		},
+		{
+			name:      "https remote url",
+			upstream:  "docker.io",
+			remoteURL: ptr.To("https://registry-1.docker.io"),
+			host:      "registry-1.docker.io",
+			port:      443,
+		},
+		{
+			name:      "remote url with port",
+			upstream:  "registry.example.com",
+			remoteURL: ptr.To("http://mirror.example.com:5000/v2"),
+			host:      "mirror.example.com",
+			port:      5000,
+		},
+		{
+			name:      "remote url without scheme",
+			upstream:  "registry.example.com",
+			remoteURL: ptr.To("mirror.example.com"),
+			wantErr:   true,
+		},
+		{
+			name:     "uppercase host with port",
+			upstream: "Registry.Example.com:5000",
+			host:     "registry.example.com",
+			port:     5000,
+		},
+		{
+			name:     "bare IPv6 address",
+			upstream: "FD00::1",
+			host:     "fd00::1",
+			port:     443,
+		},
+		{
+			name:     "bracketed IPv6 address with port",
+			upstream: "[fd00::1]:5000",
+			host:     "fd00::1",
+			port:     5000,
+		},
+		{
+			name:     "empty upstream",
+			upstream: "",
+			wantErr:  true,
+		},
+		{
+			name:     "port out of range",
+			upstream: "docker.io:77777",
+			wantErr:  true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &registrycache.RegistryCacheConfig{Spec: registrycache.RegistryCacheConfigSpec{Upstream: tt.upstream, RemoteURL: tt.remoteURL}}
+
+			host, port, err := EffectiveUpstream(config)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.host, host)
+			require.Equal(t, tt.port, port)
+		})
+	}
+}
+
+func FuzzParseUpstream(f *testing.F) {
+	for _, seed := range []string{
+		"", "docker.io", "my-registry.internal:5000", "10.0.0.1:5000", "Registry.Example.com", "my_registry.io",
+		".registry.io:5000", "registry..io:0", "https://docker.io", "docker.io/library", "docker.io:5000/",
+		"docker.io:77777", "docker.io:", "fd00::1", "[fd00::1]", "[fd00::1]:5000", "[fd00::1", "[fd00::1]5000",
+		"[fe80::1%eth0]:443", "1.2.3.4:5:6", "docker.io?foo=bar", "docker.io#tag",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, upstream string) {
+		host, port, err := parseUpstream(upstream)
+		if err != nil {
+			return
+		}
+
+		formatted := host
+		if port != 0 {
+			formatted = net.JoinHostPort(host, strconv.Itoa(port))
+		} else if strings.Contains(host, ":") {
+			formatted = "[" + host + "]"
+		}
+
+		reparsedHost, reparsedPort, err := parseUpstream(formatted)
+		require.NoError(t, err, "re-parsing %q formatted from %q", formatted, upstream)
+		require.Equal(t, host, reparsedHost)
+		require.Equal(t, port, reparsedPort)
+	})
+}
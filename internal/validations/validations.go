@@ -1,27 +1,335 @@
 package validations
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
 	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
+// ValidationResult separates errors that must block admission from advisory warnings
+type ValidationResult struct {
+	Errors   field.ErrorList
+	Warnings field.ErrorList
+}
+
 type Validator struct {
-	secrets         []v1.Secret
+	secretGetter    SecretGetter
 	existingConfigs []registrycache.RegistryCacheConfig
+	options         ValidationOptions
+	recorder        *ruleRecorder
+	demoted         *demotedErrors
 }
 
 func NewValidator(secrets []v1.Secret, existingConfigs []registrycache.RegistryCacheConfig) Validator {
+	return NewValidatorWithOptions(secrets, existingConfigs, ValidationOptions{})
+}
+
+// NewValidatorWithOptions indexes the secrets once, so the validator can be reused for many configs without scanning them each time
+func NewValidatorWithOptions(secrets []v1.Secret, existingConfigs []registrycache.RegistryCacheConfig, options ValidationOptions) Validator {
+	return NewValidatorWithSecretGetter(newSecretIndex(secrets), existingConfigs, options)
+}
+
+// NewValidatorWithSecretGetter looks referenced secrets up on demand instead of requiring all of them to be listed upfront
+func NewValidatorWithSecretGetter(secretGetter SecretGetter, existingConfigs []registrycache.RegistryCacheConfig, options ValidationOptions) Validator {
 	return Validator{
-		secrets:         secrets,
+		secretGetter:    secretGetter,
 		existingConfigs: existingConfigs,
+		options:         options,
 	}
 }
 
 func (v Validator) Do(newConfig *registrycache.RegistryCacheConfig) field.ErrorList {
+	return v.DoContext(context.Background(), newConfig)
+}
+
+// DoContext skips the field groups, storage class and secret lookups not yet started once the context is done and returns
+// the errors gathered so far together with an internal error, so that an interrupted validation is never mistaken for a
+// successful one
+func (v Validator) DoContext(ctx context.Context, newConfig *registrycache.RegistryCacheConfig) field.ErrorList {
+	errs, _ := v.validate(ctx, newConfig, field.NewPath("spec"))
+	return errs
+}
+
+// DoAtPath prefixes the emitted field paths with basePath instead of spec, for configs embedded in a larger object
+func (v Validator) DoAtPath(newConfig *registrycache.RegistryCacheConfig, basePath *field.Path) field.ErrorList {
+	errs, _ := v.validate(context.Background(), newConfig, basePath)
+	return errs
+}
+
+// DoAggregate returns the validation errors as a single error, nil when the config is valid
+func (v Validator) DoAggregate(newConfig *registrycache.RegistryCacheConfig) error {
+	return v.Do(newConfig).ToAggregate()
+}
+
+// DoWithWarnings returns the validation errors along with non-fatal warnings that should be surfaced to operators
+func (v Validator) DoWithWarnings(newConfig *registrycache.RegistryCacheConfig) (field.ErrorList, field.ErrorList) {
+	return v.validate(context.Background(), newConfig, field.NewPath("spec"))
+}
+
+func (v Validator) DoDetailed(newConfig *registrycache.RegistryCacheConfig) ValidationResult {
+	errs, warnings := v.validate(context.Background(), newConfig, field.NewPath("spec"))
+
+	return ValidationResult{
+		Errors:   errs,
+		Warnings: warnings,
+	}
+}
+
+func (v Validator) validate(ctx context.Context, newConfig *registrycache.RegistryCacheConfig, specPath *field.Path) (field.ErrorList, field.ErrorList) {
+	v.demoted = &demotedErrors{}
+
+	if newConfig.Spec == (registrycache.RegistryCacheConfigSpec{}) {
+		return field.ErrorList{field.Required(specPath, "spec cannot be empty")}, nil
+	}
+
+	groups := []func() (field.ErrorList, field.ErrorList){
+		func() (errs field.ErrorList, warnings field.ErrorList) {
+			upstreamErrs := validateUpstream(newConfig.Spec.Upstream, specPath.Child("upstream"), v.rules())
+			if len(upstreamErrs) == 0 {
+				upstreamErrs = v.validateUpstreamPolicy(newConfig.Spec.Upstream, specPath.Child("upstream"))
+				upstreamErrs = append(upstreamErrs, v.validateUpstreamNotPrivate(newConfig.Spec.Upstream, specPath.Child("upstream"))...)
+			}
+
+			if len(upstreamErrs) == 0 {
+				warnings = append(warnings, v.rules().check(RuleUpstreamDefaultPort, func() field.ErrorList {
+					return v.warnOnUpstream(newConfig.Spec.Upstream, specPath.Child("upstream"))
+				})...)
+
+				warnings = append(warnings, v.rules().check(RuleUpstreamWellKnownPort, func() field.ErrorList {
+					return v.warnOnWellKnownRegistryPort(newConfig.Spec.Upstream, specPath.Child("upstream"))
+				})...)
+
+				warnings = append(warnings, v.rules().check(RuleUpstreamIPLiteral, func() field.ErrorList {
+					return warnOnIPLiteralUpstream(newConfig.Spec.Upstream, specPath.Child("upstream"))
+				})...)
+
+				warnings = append(warnings, v.warnOnSelfReferencingUpstream(newConfig.Spec.Upstream, specPath.Child("upstream"))...)
+			}
+
+			errs = append(errs, upstreamErrs...)
+			errs = append(errs, v.validateUpstreamUniqueness(newConfig.Spec.Upstream, specPath.Child("upstream"))...)
+
+			if newConfig.Spec.RemoteURL != nil {
+				errs = append(errs, v.rules().check(RuleRemoteURLScheme, func() field.ErrorList {
+					return validateURL(*newConfig.Spec.RemoteURL, specPath.Child("remoteURL"))
+				})...)
+			}
+
+			return errs, warnings
+		},
+		func() (field.ErrorList, field.ErrorList) {
+			return v.validateVolume(ctx, newConfig.Spec.Volume, specPath.Child("volume")), v.warnOnVolume(newConfig.Spec.Volume, specPath.Child("volume"))
+		},
+		func() (field.ErrorList, field.ErrorList) {
+			return v.validateGarbageCollection(newConfig.Spec.GarbageCollection, specPath.Child("garbageCollection")), v.warnOnGarbageCollection(newConfig.Spec, specPath.Child("garbageCollection"))
+		},
+		func() (field.ErrorList, field.ErrorList) {
+			if errs := v.validateProxyAllowed(newConfig.Spec.Proxy, specPath.Child("proxy")); len(errs) > 0 {
+				return errs, nil
+			}
+
+			errs := validateProxy(newConfig.Spec.Proxy, specPath.Child("proxy"), v.rules())
+			errs = append(errs, v.validateProxyForbiddenCIDRs(newConfig.Spec.Proxy, specPath.Child("proxy"))...)
+
+			warnings := v.rules().check(RuleProxyInternalUpstream, func() field.ErrorList {
+				return warnOnProxyForInternalUpstream(newConfig.Spec.Upstream, newConfig.Spec.Proxy, specPath.Child("proxy"))
+			})
+			warnings = append(warnings, v.rules().check(RuleProxyDefaultPort, func() field.ErrorList {
+				return warnOnProxyWithoutPort(newConfig.Spec.Proxy, specPath.Child("proxy"), errs)
+			})...)
+
+			return errs, warnings
+		},
+		func() (field.ErrorList, field.ErrorList) {
+			if newConfig.Spec.SecretReferenceName == nil {
+				return v.validateSecretRequired(newConfig.Spec.Upstream, specPath.Child("secretReferenceName")), nil
+			}
+
+			// the secret is looked up in the namespace of the config, without one the lookup would report a misleading not found
+			if errs := v.rules().check(RuleSecretNamespace, func() field.ErrorList {
+				if newConfig.Namespace == "" {
+					return field.ErrorList{field.Required(field.NewPath("metadata").Child("namespace"), "namespace must be set to look up the secret referenced by spec.secretReferenceName")}
+				}
+
+				return nil
+			}); len(errs) > 0 {
+				return errs, nil
+			}
+
+			return v.validateSecretReference(ctx, newConfig.Namespace, *newConfig.Spec.SecretReferenceName, newConfig.Spec.Upstream, specPath.Child("secretReferenceName"))
+		},
+	}
+
+	errs, warnings := runFieldGroups(ctx, groups, specPath)
+
+	if v.options.Strict {
+		errs = replaceWithStrictErrors(errs, v.rules().check(RuleEmptyOptionalField, func() field.ErrorList {
+			return validateEmptyOptionalFields(newConfig.Spec, specPath)
+		}))
+	}
+
+	if len(v.demoted.errs) > 0 {
+		warnings = append(warnings, v.demoted.errs...)
+		sortErrors(warnings)
+	}
+
+	return errs, warnings
+}
+
+type fieldGroupResult struct {
+	errs     field.ErrorList
+	warnings field.ErrorList
+	skipped  bool
+}
+
+// runFieldGroups validates the independent field groups concurrently, a group that panics is reported as an internal error
+// and the merged lists are sorted by field path so that the output does not depend on scheduling
+func runFieldGroups(ctx context.Context, groups []func() (field.ErrorList, field.ErrorList), specPath *field.Path) (field.ErrorList, field.ErrorList) {
+	results := make([]fieldGroupResult, len(groups))
+
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					results[i] = fieldGroupResult{errs: field.ErrorList{field.InternalError(specPath, fmt.Errorf("validation panicked: %v", r))}}
+				}
+			}()
+
+			if ctx.Err() != nil {
+				results[i].skipped = true
+				return
+			}
+
+			results[i].errs, results[i].warnings = group()
+		}()
+	}
+	wg.Wait()
+
+	var errs, warnings field.ErrorList
+	var skipped bool
+
+	for _, result := range results {
+		errs = append(errs, result.errs...)
+		warnings = append(warnings, result.warnings...)
+		skipped = skipped || result.skipped
+	}
+
+	if skipped {
+		errs = append(errs, interrupted(ctx, specPath)...)
+	}
+
+	sortErrors(errs)
+	sortErrors(warnings)
+
+	return errs, warnings
+}
+
+// interrupted reports an internal error once the context is done, it is checked before each lookup so that a slow lister or
+// secret getter is not called after the caller has given up
+func interrupted(ctx context.Context, fldPath *field.Path) field.ErrorList {
+	if err := ctx.Err(); err != nil {
+		return field.ErrorList{field.InternalError(fldPath, fmt.Errorf("validation interrupted: %w", err))}
+	}
+
 	return nil
 }
 
-func (v Validator) DoOnUpdate(newConfig, oldConfig *registrycache.RegistryCacheConfig) field.ErrorList {
+// sortErrors orders errors by field path and then by type so that identical inputs always produce identical output
+func sortErrors(errs field.ErrorList) {
+	slices.SortStableFunc(errs, func(a, b *field.Error) int {
+		if c := strings.Compare(a.Field, b.Field); c != 0 {
+			return c
+		}
+
+		return strings.Compare(string(a.Type), string(b.Type))
+	})
+}
+
+func (v Validator) validateUpstreamUniqueness(upstream string, fldPath *field.Path) field.ErrorList {
+	return v.rules().check(RuleUpstreamUnique, func() field.ErrorList {
+		normalized := normalizeUpstream(upstream)
+
+		for _, existingConfig := range v.existingConfigs {
+			if normalizeUpstream(existingConfig.Spec.Upstream) == normalized {
+				return field.ErrorList{field.Invalid(fldPath, upstream, "duplicated upstream")}
+			}
+		}
+
+		return nil
+	})
+}
+
+func validateURL(url string, fldPath *field.Path) field.ErrorList {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return field.ErrorList{field.Invalid(fldPath, url, "url must start with 'http://' or 'https://'")}
+	}
+
+	return nil
+}
+
+// validateHost accepts IP literals and RFC 1123 subdomains, hostnames are case-insensitive so they are lowercased first
+func validateHost(host, value string, fldPath *field.Path) field.ErrorList {
+	if _, err := netip.ParseAddr(host); err == nil {
+		return nil
+	}
+
+	// digits and dots alone also pass as a subdomain, e.g. 999.999.999.999, but can only be meant as an IPv4 address
+	if host != "" && strings.Trim(host, "0123456789.") == "" {
+		return field.ErrorList{field.Invalid(fldPath, value, fmt.Sprintf("host %s is not a valid IPv4 address", host))}
+	}
+
+	// the generic message neither names the actual length nor checks the length of the individual labels
+	if len(host) > validation.DNS1123SubdomainMaxLength {
+		return field.ErrorList{field.Invalid(fldPath, value, fmt.Sprintf("host is %d characters long which exceeds the DNS limit of %d characters", len(host), validation.DNS1123SubdomainMaxLength))}
+	}
+
+	var errs field.ErrorList
+
+	for _, label := range strings.Split(host, ".") {
+		if len(label) > validation.DNS1123LabelMaxLength {
+			errs = append(errs, field.Invalid(fldPath, value, fmt.Sprintf("host label %q is %d characters long which exceeds the DNS limit of %d characters per label", label, len(label), validation.DNS1123LabelMaxLength)))
+		}
+	}
+
+	for _, msg := range validation.IsDNS1123Subdomain(strings.ToLower(host)) {
+		errs = append(errs, field.Invalid(fldPath, value, msg))
+	}
+
+	return errs
+}
+
+func validatePortField(value, host, portStr string, fldPath *field.Path) field.ErrorList {
+	if err := validatePort(host, portStr); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, value, err.Error())}
+	}
+
+	return nil
+}
+
+// validatePort checks the port of a host:port pair, it only depends on the standard library so that it can be reused outside
+// of Kubernetes, e.g. in a CLI
+func validatePort(host, portStr string) error {
+	if host == "" {
+		return fmt.Errorf("port %s is not preceded by a host", portStr)
+	}
+
+	if port, err := strconv.Atoi(portStr); err != nil || port < 1 || port > 65535 {
+		return errors.New("valid port must be in the range [1, 65535]")
+	}
+
 	return nil
 }
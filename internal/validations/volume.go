@@ -0,0 +1,205 @@
+package validations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	registrycache "github.com/kyma-project/kim-snatch/api/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func (v Validator) validateVolume(ctx context.Context, volume *registrycache.Volume, fldPath *field.Path) field.ErrorList {
+	if volume == nil {
+		return nil
+	}
+
+	var errs field.ErrorList
+
+	if volume.Size != nil {
+		errs = append(errs, v.validateVolumeSize(*volume.Size, fldPath.Child("size"))...)
+	}
+
+	if volume.StorageClassName != nil {
+		errs = append(errs, v.validateStorageClassName(ctx, *volume.StorageClassName, fldPath.Child("storageClassName"))...)
+	}
+
+	return errs
+}
+
+// ValidateVolumeSize checks a single volume size against the default options, e.g. to give feedback while it is typed
+func ValidateVolumeSize(size resource.Quantity, fldPath *field.Path) field.ErrorList {
+	return Validator{}.validateVolumeSize(size, fldPath)
+}
+
+func (v Validator) validateVolumeSize(size resource.Quantity, fldPath *field.Path) field.ErrorList {
+	rules := v.rules()
+
+	if errs := rules.check(RuleVolumeSizePositive, func() field.ErrorList {
+		if size.Sign() <= 0 {
+			return field.ErrorList{field.Invalid(fldPath, size.String(), "must be greater than 0")}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	maxSize := v.options.MaxVolumeSize
+	if maxSize == nil {
+		return nil
+	}
+
+	return rules.check(RuleVolumeSizeMax, func() field.ErrorList {
+		if size.Cmp(*maxSize) > 0 {
+			return field.ErrorList{field.Invalid(fldPath, size.String(), fmt.Sprintf("requested size %s exceeds the maximum allowed size %s", size.String(), maxSize.String()))}
+		}
+
+		return nil
+	})
+}
+
+// ValidateStorageClassName checks the format of a single storage class name, the allow list and the existence check need options
+func ValidateStorageClassName(name string, fldPath *field.Path) field.ErrorList {
+	return Validator{}.validateStorageClassName(context.Background(), name, fldPath)
+}
+
+func (v Validator) validateStorageClassName(ctx context.Context, name string, fldPath *field.Path) field.ErrorList {
+	rules := v.rules()
+
+	if errs := rules.check(RuleStorageClassNameFormat, func() field.ErrorList {
+		if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+			details := append(describeInvalidCharacters(name), msgs...)
+			return field.ErrorList{field.Invalid(fldPath, name, strings.Join(details, "; "))}
+		}
+
+		return nil
+	}); len(errs) > 0 {
+		return errs
+	}
+
+	if len(v.options.AllowedStorageClassNames) > 0 {
+		if errs := rules.check(RuleStorageClassNameAllowed, func() field.ErrorList {
+			if slices.Contains(v.options.AllowedStorageClassNames, name) {
+				return nil
+			}
+
+			allowed := slices.Clone(v.options.AllowedStorageClassNames)
+			slices.Sort(allowed)
+
+			return field.ErrorList{field.NotSupported(fldPath, name, allowed)}
+		}); len(errs) > 0 {
+			return errs
+		}
+	}
+
+	if v.options.StorageClassLister == nil {
+		return nil
+	}
+
+	if errs := interrupted(ctx, fldPath); len(errs) > 0 {
+		return errs
+	}
+
+	return rules.check(RuleStorageClassNameExists, func() field.ErrorList {
+		exists, err := v.options.StorageClassLister.Exists(name)
+		if err != nil {
+			return field.ErrorList{field.InternalError(fldPath, fmt.Errorf("failed to check if storage class %q exists: %w", name, err))}
+		}
+
+		if !exists {
+			return field.ErrorList{field.NotFound(fldPath, name)}
+		}
+
+		return nil
+	})
+}
+
+// describeInvalidCharacters points at the characters not allowed in an RFC 1123 subdomain, which the generic message does not name
+func describeInvalidCharacters(name string) []string {
+	var details []string
+
+	for i, r := range name {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			details = append(details, fmt.Sprintf("contains uppercase '%c' at position %d", r, i))
+		case (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '.':
+			details = append(details, fmt.Sprintf("contains invalid character '%c' at position %d", r, i))
+		}
+	}
+
+	return details
+}
+
+func (v Validator) warnOnVolume(volume *registrycache.Volume, fldPath *field.Path) field.ErrorList {
+	rules := v.rules()
+
+	if warnings := rules.check(RuleVolumeSizeUnset, func() field.ErrorList {
+		if volume == nil || volume.Size == nil {
+			return field.ErrorList{field.Required(fldPath.Child("size"), "volume size is not set, the platform default may be too small and the cache can fill up")}
+		}
+
+		return nil
+	}); len(warnings) > 0 || volume == nil || volume.Size == nil {
+		return warnings
+	}
+
+	warnings := rules.check(RuleVolumeSizeDecimalSuffix, func() field.ErrorList {
+		return warnOnDecimalVolumeSize(*volume.Size, fldPath.Child("size"))
+	})
+
+	if v.options.VolumeSizeGranularity != nil {
+		warnings = append(warnings, rules.check(RuleVolumeSizeGranularity, func() field.ErrorList {
+			return v.warnOnVolumeSizeGranularity(*volume.Size, fldPath.Child("size"))
+		})...)
+	}
+
+	return warnings
+}
+
+func (v Validator) warnOnVolumeSizeGranularity(size resource.Quantity, fldPath *field.Path) field.ErrorList {
+	granularity := v.options.VolumeSizeGranularity
+	if granularity == nil || granularity.Sign() <= 0 || size.Sign() <= 0 {
+		return nil
+	}
+
+	remainder := size.Value() % granularity.Value()
+	if remainder == 0 {
+		return nil
+	}
+
+	rounded := size.DeepCopy()
+	rounded.Add(*resource.NewQuantity(granularity.Value()-remainder, size.Format))
+
+	return field.ErrorList{field.Invalid(fldPath, size.String(), fmt.Sprintf("size is not a multiple of %s and will be rounded up to %s when provisioned", granularity.String(), rounded.String()))}
+}
+
+var binarySuffixes = map[string]string{
+	"k": "Ki",
+	"M": "Mi",
+	"G": "Gi",
+	"T": "Ti",
+	"P": "Pi",
+	"E": "Ei",
+}
+
+func warnOnDecimalVolumeSize(size resource.Quantity, fldPath *field.Path) field.ErrorList {
+	if size.Format != resource.DecimalSI {
+		return nil
+	}
+
+	value := size.String()
+	decimalSuffix := strings.TrimLeft(value, "0123456789.")
+
+	binarySuffix, found := binarySuffixes[decimalSuffix]
+	if !found {
+		return nil
+	}
+
+	suggested := strings.TrimSuffix(value, decimalSuffix) + binarySuffix
+
+	return field.ErrorList{field.Invalid(fldPath, value, fmt.Sprintf("size uses the decimal suffix %q, use %s instead if binary units were intended", decimalSuffix, suggested))}
+}
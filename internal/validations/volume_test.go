@@ -0,0 +1,69 @@
+package validations
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateVolumeSize(t *testing.T) {
+	fldPath := field.NewPath("size")
+
+	for _, tt := range []struct {
+		name       string
+		size       resource.Quantity
+		errorsList field.ErrorList
+	}{
+		{
+			name:       "positive size",
+			size:       resource.MustParse("10Gi"),
+			errorsList: field.ErrorList{},
+		},
+		{
+			name: "zero size",
+			size: resource.MustParse("0"),
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "0", "must be greater than 0"),
+			},
+		},
+		{
+			name: "negative size",
+			size: resource.MustParse("-1Gi"),
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "-1Gi", "must be greater than 0"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requireErrorsMatch(t, tt.errorsList, ValidateVolumeSize(tt.size, fldPath))
+		})
+	}
+}
+
+func TestValidateStorageClassName(t *testing.T) {
+	fldPath := field.NewPath("storageClassName")
+
+	for _, tt := range []struct {
+		name             string
+		storageClassName string
+		errorsList       field.ErrorList
+	}{
+		{
+			name:             "valid name",
+			storageClassName: "premium-ssd",
+			errorsList:       field.ErrorList{},
+		},
+		{
+			name:             "uppercase name",
+			storageClassName: "Premium",
+			errorsList: field.ErrorList{
+				field.Invalid(fldPath, "Premium", "a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters"),
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requireErrorsMatch(t, tt.errorsList, ValidateStorageClassName(tt.storageClassName, fldPath))
+		})
+	}
+}